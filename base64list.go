@@ -0,0 +1,72 @@
+package libconfig
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// isBytesList returns true if the type is a [][]byte
+func isBytesList(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && isBytes(t.Elem()) && t.Elem().Kind() == reflect.Slice
+}
+
+// lenientEncodings are the variants of base64 that decodeBase64 falls back to, in order,
+// if it is lenient
+var lenientEncodings = []*base64.Encoding{
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// decodeBase64 decodes the value as standard, padded base64 or, if lenient and that
+// fails, as unpadded or URL-safe base64. If every encoding fails, the error is the one
+// for standard base64.
+func decodeBase64(value string, lenient bool) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err == nil || !lenient {
+		return data, err
+	}
+
+	for _, encoding := range lenientEncodings {
+		if data, lenientErr := encoding.DecodeString(value); lenientErr == nil {
+			return data, nil
+		}
+	}
+
+	return nil, err
+}
+
+// parseBase64List splits the value on commas and base64-decodes (and, if tagged,
+// decompresses) each item into a new [][]byte, leniently if specified. Errors identify
+// the index of the failing item, e.g. BLOBS[1].
+func parseBase64List(v reflect.Value, tag tagData, value string, lenient bool) error {
+	var items []string
+	if value != "" {
+		items = strings.Split(value, ",")
+	}
+
+	list := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		key := tag.Name + "[" + strconv.Itoa(i) + "]"
+		item = strings.TrimSpace(item)
+
+		data, err := decodeBase64(item, lenient)
+		if err != nil {
+			return NewErrDecodeFailure(err, key, item, "base64")
+		}
+
+		if tag.Gzip {
+			data, err = gunzip(data)
+			if err != nil {
+				return NewErrDecodeFailure(err, key, item, "gzip")
+			}
+		}
+
+		list.Index(i).SetBytes(data)
+	}
+
+	v.Set(list)
+	return nil
+}
@@ -0,0 +1,148 @@
+package libconfig
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// cacheInit guards the lazy creation of each Parser's cache
+var cacheInit sync.Mutex
+
+// cache holds data that is expensive to compute and can be shared by every Get
+// call on a Parser
+type cache struct {
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+	fields   map[fieldsKey][]fieldData
+	plans    map[planKey]*Plan
+	prepared map[planKey]*Plan
+}
+
+// fieldsKey identifies the fields of a struct type as parsed with a given tag, prefix,
+// and the settings of the Parser that affect the tag data. Tag holds the names of the
+// tags joined by commas if the Parser has more than one. Names that are derived from
+// the names of fields are not cached, since the NameFunc cannot be compared.
+type fieldsKey struct {
+	Type                 reflect.Type
+	Tag                  string
+	Prefix               string
+	AutoName             bool
+	IgnoreUnknownOptions bool
+}
+
+// fieldsKey returns the key for the fields of the struct type with the prefix
+func (p *Parser) fieldsKey(t reflect.Type, prefix string) fieldsKey {
+	return fieldsKey{
+		Type:                 t,
+		Tag:                  strings.Join(p.tagNames(), ","),
+		Prefix:               prefix,
+		AutoName:             p.AutoName,
+		IgnoreUnknownOptions: p.IgnoreUnknownOptions,
+	}
+}
+
+// fieldData holds the parsed tag and classification of a struct field
+type fieldData struct {
+	Index    int
+	Field    reflect.StructField
+	Tag      tagData
+	IsStruct bool
+}
+
+// cache returns the Parser's cache, creating it if necessary. The cache is held by
+// pointer so that a Parser can still be copied.
+func (p *Parser) cache() *cache {
+	cacheInit.Lock()
+	defer cacheInit.Unlock()
+
+	if p.c == nil {
+		p.c = &cache{
+			patterns: make(map[string]*regexp.Regexp),
+			fields:   make(map[fieldsKey][]fieldData),
+			plans:    make(map[planKey]*Plan),
+			prepared: make(map[planKey]*Plan),
+		}
+	}
+
+	return p.c
+}
+
+// compile compiles the pattern, reusing the result of any previous compilation
+func (p *Parser) compile(pattern string) (*regexp.Regexp, error) {
+	c := p.cache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if re, ok := c.patterns[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, NewErrInvalidPattern(pattern, err)
+	}
+	c.patterns[pattern] = re
+
+	return re, nil
+}
+
+// fields returns the data for each field of the struct type, parsing the tags only
+// the first time the type is seen with the Parser's tag and the given prefix. If a tag
+// fails to parse, the data for the preceding fields is returned along with the error,
+// and nothing is cached.
+func (p *Parser) fields(t reflect.Type, prefix string) ([]fieldData, error) {
+	key := p.fieldsKey(t, prefix)
+
+	c := p.cache()
+	c.mu.Lock()
+	fields, ok := c.fields[key]
+	c.mu.Unlock()
+	if ok {
+		return p.named(fields, prefix), nil
+	}
+
+	fields = make([]fieldData, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, err := p.parseTagOptions(field, prefix)
+		if err != nil {
+			return p.named(fields, prefix), err
+		}
+
+		fields = append(fields, fieldData{
+			Index:    i,
+			Field:    field,
+			Tag:      tag,
+			IsStruct: isStruct(field.Type),
+		})
+	}
+
+	c.mu.Lock()
+	c.fields[key] = fields
+	c.mu.Unlock()
+
+	return p.named(fields, prefix), nil
+}
+
+// named returns the fields with the names derived for the tags whose names were
+// omitted, copying the fields rather than changing the cached ones
+func (p *Parser) named(fields []fieldData, prefix string) []fieldData {
+	var named []fieldData
+	for i, f := range fields {
+		if !f.Tag.AutoNamed {
+			continue
+		}
+		if named == nil {
+			named = append([]fieldData{}, fields...)
+		}
+		named[i].Tag.Name = p.derivedName(f.Field, f.Tag, prefix)
+	}
+
+	if named == nil {
+		return fields
+	}
+
+	return named
+}
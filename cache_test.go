@@ -0,0 +1,140 @@
+package libconfig_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type cachedConfig struct {
+	VarA   string  `env:"VAR_A"`
+	VarB   int     `env:"VAR_B,optional"`
+	VarC   *uint16 `env:"VAR_C,min=1"`
+	VarD   []int   `env:"VAR_D,json"`
+	Nested struct {
+		VarE float64 `env:"VAR_E"`
+		VarF bool    `env:"VAR_F"`
+	}
+	Database struct {
+		Host string `env:"HOST"`
+	} `env:"DB_,prefix"`
+}
+
+var cachedEnvs = map[string]string{
+	"VAR_A":   "VAL_A",
+	"VAR_C":   "3",
+	"VAR_D":   "[1,2,3]",
+	"VAR_E":   "1.5",
+	"VAR_F":   "true",
+	"DB_HOST": "localhost",
+}
+
+func TestCachedGet(t *testing.T) {
+	p := mapToParser(cachedEnvs)
+
+	uncached := cachedConfig{}
+	err := p.Get(&uncached)
+	require.NoError(t, err, "the first Get should not fail")
+
+	cached := cachedConfig{}
+	err = p.Get(&cached)
+	require.NoError(t, err, "the second Get should not fail")
+
+	require.Equal(t, uncached, cached, "the cached Get should produce identical results")
+	require.Equal(t, "localhost", cached.Database.Host, "the prefix should be applied")
+}
+
+func TestCachedGetError(t *testing.T) {
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_C": "0",
+	})
+
+	uncached := cachedConfig{}
+	uncachedErr := p.Get(&uncached)
+
+	cached := cachedConfig{}
+	cachedErr := p.Get(&cached)
+
+	require.Error(t, uncachedErr, "the first Get should fail")
+	require.Equal(t, uncachedErr, cachedErr, "the cached Get should produce an identical error")
+	require.Equal(t, uncached, cached, "the cached Get should produce identical results")
+}
+
+func TestCachedGetPrefix(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":     "VAL_A",
+		"APP_VAR_A": "APP_VAL_A",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	require.NoError(t, err, "Get should not fail")
+	require.Equal(t, "VAL_A", config.VarA, "VarA should parse correctly")
+
+	p.Prefix = "APP_"
+	err = p.Get(&config)
+	require.NoError(t, err, "Get should not fail")
+	require.Equal(t, "APP_VAL_A", config.VarA, "changing the prefix should not use stale cached names")
+}
+
+func TestCachedGetBadTag(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,not-a-valid-option"`
+	}
+
+	p := mapToParser(nil)
+	expected := libconfig.NewErrVarNotFound("VAR_A")
+
+	err := p.Get(&Config{})
+	require.Equal(t, expected, err, "fields preceding a bad tag should be parsed first")
+
+	err = p.Get(&Config{})
+	require.Equal(t, expected, err, "a bad tag should not be cached")
+}
+
+func TestCachedGetConcurrent(t *testing.T) {
+	p := mapToParser(cachedEnvs)
+
+	configs := make([]cachedConfig, 8)
+	errs := make([]error, len(configs))
+
+	var wg sync.WaitGroup
+	for i := range configs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = p.Get(&configs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range configs {
+		require.NoError(t, errs[i], "Get should not fail")
+		require.Equal(t, "VAL_A", configs[i].VarA, "VarA should parse correctly")
+	}
+}
+
+func BenchmarkGetUncached(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p := mapToParser(cachedEnvs)
+		config := cachedConfig{}
+		_ = p.Get(&config)
+	}
+}
+
+func BenchmarkGetCached(b *testing.B) {
+	p := mapToParser(cachedEnvs)
+	for i := 0; i < b.N; i++ {
+		config := cachedConfig{}
+		_ = p.Get(&config)
+	}
+}
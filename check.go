@@ -0,0 +1,172 @@
+package libconfig
+
+import "reflect"
+
+// configType returns the struct type of the config, which may be a struct or a pointer
+// to a struct, for the methods that only read the type
+func configType(config interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(config)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, NewErrInvalidConfigType(reflect.TypeOf(config))
+	}
+
+	return t, nil
+}
+
+// Check validates the tags of the config's type, which may be a struct or a pointer to a
+// struct, without consulting the LookupFn, so that a typo such as `optinal` can fail in
+// an init function or a unit test rather than when Get runs in production. It returns
+// the first error in a tag, e.g. ErrMissingNameTag, ErrInvalidTagOption, ErrNestedTags,
+// ErrConflictingOptions or ErrInvalidPattern, or, if the Parser collects errors, an
+// ErrMultiple holding all of them. The elements of indexed slices are checked too.
+func (p *Parser) Check(config interface{}) error {
+	t, err := configType(config)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	p.check(t, p.Prefix, &errs)
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case p.CollectErrors:
+		return NewErrMultiple(errs)
+	default:
+		return errs[0]
+	}
+}
+
+// check appends the errors in the tags of the struct type to errs, following the same
+// rules as parse and stopping at the first unless the Parser collects errors, and
+// returns true if the struct has any tagged fields
+func (p *Parser) check(t reflect.Type, prefix string, errs *[]error) bool {
+	var tagFound bool
+
+	// fail records the error and returns true if checking must stop
+	fail := func(err error) bool {
+		*errs = append(*errs, err)
+		return !p.CollectErrors
+	}
+	stopped := func() bool {
+		return !p.CollectErrors && len(*errs) > 0
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, err := p.parseTag(field, prefix)
+		if err != nil {
+			if fail(err) {
+				return tagFound
+			}
+			continue
+		}
+
+		if tag.Pattern != "" {
+			_, err = p.compile(tag.Pattern)
+			if err != nil && fail(err) {
+				return tagFound
+			}
+		}
+
+		if tag.Indexed {
+			tagFound = true
+
+			// Each element is parsed like a struct with its own prefix
+			elemType := derefType(field.Type.Elem())
+			if elemType.Kind() == reflect.Struct {
+				p.check(elemType, indexedPrefix(tag.Name, 0), errs)
+				if stopped() {
+					return tagFound
+				}
+			}
+		} else if tag.Tagged && !tag.Prefix {
+			tagFound = true
+		}
+
+		// The tags of a struct tagged with kv hold keys, which are checked on their own
+		if tag.KV {
+			p.check(derefType(field.Type), "", errs)
+			if stopped() {
+				return tagFound
+			}
+			continue
+		}
+
+		if isStruct(field.Type) && !(tag.Tagged && !tag.Prefix && p.AllowNestedTags) {
+			nestedPrefix := prefix
+			if tag.Prefix {
+				nestedPrefix = tag.Name
+			}
+
+			found := p.check(derefType(field.Type), nestedPrefix, errs)
+			if stopped() {
+				return tagFound
+			}
+
+			if tag.Tagged && !tag.Prefix && found && fail(NewErrNestedTags(field.Name, tag.Name)) {
+				return tagFound
+			}
+			tagFound = tagFound || found
+		}
+	}
+
+	return tagFound
+}
+
+// GetStrict is like Get, but first returns an ErrUntaggedField for any exported field
+// that has no tag, e.g. because it was added and its tag was forgotten. Fields that
+// hold structs, including embedded structs, are not variables themselves, so only
+// their fields must be tagged.
+func (p *Parser) GetStrict(config interface{}) error {
+	v := reflect.ValueOf(config)
+	if t := v.Type(); !(t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct) {
+		return p.result(NewErrInvalidConfigType(t))
+	}
+
+	err := p.untagged(v.Type().Elem(), p.Prefix, "")
+	if err != nil {
+		return p.result(err)
+	}
+
+	return p.Get(config)
+}
+
+// untagged returns an ErrUntaggedField for the first exported field of the struct type
+// that has no tag, following the same rules as parse. Errors in the tags themselves are
+// left to Get, so only the fields preceding such an error are checked.
+func (p *Parser) untagged(t reflect.Type, prefix, path string) error {
+	fields, _ := p.fields(t, prefix)
+
+	for _, f := range fields {
+		var err error
+		field := f.Field
+		tag := f.Tag
+		if field.PkgPath != "" {
+			continue
+		}
+
+		switch {
+		case tag.Indexed:
+			elemType := derefType(field.Type.Elem())
+			err = p.untagged(elemType, indexedPrefix(tag.Name, 0), path+field.Name+"[].")
+		case f.IsStruct && (!tag.Tagged || tag.Prefix):
+			nestedPrefix := prefix
+			if tag.Prefix {
+				nestedPrefix = tag.Name
+			}
+			err = p.untagged(derefType(field.Type), nestedPrefix, path+field.Name+".")
+		case !tag.Tagged:
+			err = NewErrUntaggedField(path + field.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
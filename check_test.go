@@ -0,0 +1,193 @@
+package libconfig_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestCheck(t *testing.T) {
+	p := mapToParser(nil)
+	p.LookupFn = func(key string) (string, bool) {
+		t.Fatalf("Check should not look up %s", key)
+		return "", false
+	}
+
+	err := p.Check(&plannedConfig{})
+	require.NoError(t, err, "Check should not fail for a valid struct")
+}
+
+func TestCheckInvalidOption(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,optinal"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Check(Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_B,optinal", "optinal")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Check should fail because of the typo")
+}
+
+func TestCheckNestedTags(t *testing.T) {
+	type Nested struct {
+		VarC int `json:"varc" env:"VAR_C"`
+	}
+	type Config struct {
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Check(&Config{})
+	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Check should fail because the struct is tagged and has tagged members")
+}
+
+func TestCheckIndexed(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST,lower,upper"`
+	}
+	type Config struct {
+		Servers []Server `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Check(&Config{})
+	expected := libconfig.NewErrConflictingOptions("HOST,lower,upper", "lower", "upper")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Check should check the elements of indexed slices")
+}
+
+func TestCheckCollectErrors(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optinal"`
+		VarB string `env:""`
+		VarC string `env:"VAR_C,pattern=["`
+		VarD string `env:"VAR_D"`
+	}
+
+	p := mapToParser(nil)
+	p.CollectErrors = true
+	err := p.Check(&Config{})
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrMultiple{}, err, "Check should collect the errors")
+	errs := err.(*libconfig.ErrMultiple).Errors
+	require.Len(errs, 3, "every invalid tag should have an error")
+	require.Equal(libconfig.NewErrInvalidTagOption("VAR_A,optinal", "optinal"), errs[0], "the typo should be reported first")
+	require.Equal(libconfig.NewErrMissingNameTag(""), errs[1], "the missing name should be reported second")
+	require.IsType(&libconfig.ErrInvalidPattern{}, errs[2], "the invalid pattern should be reported last")
+}
+
+func TestCheckInvalidConfigType(t *testing.T) {
+	p := mapToParser(nil)
+	err := p.Check(1)
+	expected := libconfig.NewErrInvalidConfigType(reflect.TypeOf(1))
+
+	require := require.New(t)
+	require.Equal(expected, err, "Check should fail with ErrInvalidConfigType")
+}
+
+func TestCheckNilConfig(t *testing.T) {
+	p := mapToParser(nil)
+	err := p.Check(nil)
+	expected := libconfig.NewErrInvalidConfigType(nil)
+
+	require.Equal(t, expected, err, "Check should fail rather than panic for a nil config")
+}
+
+func TestCheckPointerAndValue(t *testing.T) {
+	p := mapToParser(nil)
+
+	require := require.New(t)
+	require.NoError(p.Check(&plannedConfig{}), "Check should accept a pointer")
+	require.NoError(p.Check(plannedConfig{}), "Check should accept a struct value")
+}
+
+func TestGetRequiresPointer(t *testing.T) {
+	p := mapToParser(nil)
+	err := p.Get(plannedConfig{})
+
+	require.IsType(t, &libconfig.ErrInvalidConfigType{}, err, "Get should still require a pointer")
+}
+
+func TestGetStrict(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+	}
+	type Embedded struct {
+		VarB string `env:"VAR_B"`
+	}
+	type Config struct {
+		Embedded
+		VarA     string   `env:"VAR_A"`
+		Database Database `env:"DB_,prefix"`
+		Nested   *struct {
+			VarC int `env:"VAR_C"`
+		}
+		unexported string
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":   "VAL_A",
+		"VAR_B":   "VAL_B",
+		"VAR_C":   "1",
+		"DB_HOST": "localhost",
+	})
+
+	config := Config{}
+	err := p.GetStrict(&config)
+
+	require := require.New(t)
+	require.NoError(err, "GetStrict should not fail because every variable is tagged")
+	require.Equal("localhost", config.Database.Host, "Host should parse correctly")
+	require.Equal("", config.unexported, "unexported fields should be ignored")
+}
+
+func TestGetStrictUntaggedField(t *testing.T) {
+	type Config struct {
+		VarA     string `env:"VAR_A"`
+		Database struct {
+			Host string `env:"HOST"`
+			Port int
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"HOST":  "localhost",
+	})
+
+	config := Config{}
+	err := p.GetStrict(&config)
+	expected := libconfig.NewErrUntaggedField("Database.Port")
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetStrict should fail because Port has no tag")
+	require.Equal("", config.VarA, "the config should not be populated")
+}
+
+func TestGetStrictIndexed(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST"`
+		Port int
+	}
+	type Config struct {
+		Servers []Server `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(nil)
+	err := p.GetStrict(&Config{})
+	expected := libconfig.NewErrUntaggedField("Servers[].Port")
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetStrict should check the elements of indexed slices")
+}
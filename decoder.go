@@ -0,0 +1,60 @@
+package libconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// DecoderFunc decodes the raw value of a variable into a value of the type for which
+// it was registered, which may be any named type, e.g. rate.Limit, a float64. The
+// decoder sees the value after it is expanded, read from a file or stdin, and
+// base64-decoded, as tagged, and must return a value of exactly the registered type.
+type DecoderFunc func(raw string) (interface{}, error)
+
+// DecoderCtxFunc is a DecoderFunc that also receives the context given to GetContext,
+// so that a decoder that does slow work, such as remote validation, can honor
+// cancellation. Get passes context.Background().
+type DecoderCtxFunc func(ctx context.Context, raw string) (interface{}, error)
+
+// RegisterDecoder registers a custom decoder for fields of the given type. A field
+// tagged with json is always decoded as JSON, regardless of any registered decoder.
+func (p *Parser) RegisterDecoder(t reflect.Type, fn DecoderFunc) {
+	p.RegisterDecoderCtx(t, func(_ context.Context, raw string) (interface{}, error) {
+		return fn(raw)
+	})
+}
+
+// RegisterDecoderCtx registers a context-aware custom decoder for fields of the given
+// type, replacing any decoder previously registered for that type.
+func (p *Parser) RegisterDecoderCtx(t reflect.Type, fn DecoderCtxFunc) {
+	if p.decoders == nil {
+		p.decoders = make(map[reflect.Type]DecoderCtxFunc)
+	}
+
+	p.decoders[t] = fn
+}
+
+// decode uses the custom decoder registered for the type of v, if any, to set v.
+// It returns false if no decoder is registered for the type.
+func (p *Parser) decode(ctx context.Context, v reflect.Value, tag tagData, value string) (bool, error) {
+	fn, ok := p.decoders[v.Type()]
+	if !ok {
+		return false, nil
+	}
+
+	result, err := fn(ctx, value)
+	if err != nil {
+		return true, NewErrDecodeFailure(err, tag.Name, value, "decoder")
+	}
+
+	r := reflect.ValueOf(result)
+	if !r.IsValid() || !r.Type().AssignableTo(v.Type()) {
+		err = fmt.Errorf("decoder returned %T but field is %s", result, v.Type())
+		return true, NewErrDecodeFailure(err, tag.Name, value, "decoder")
+	}
+
+	v.Set(r)
+
+	return true, nil
+}
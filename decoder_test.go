@@ -0,0 +1,213 @@
+package libconfig_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type upper string
+
+// limit stands in for rate.Limit from golang.org/x/time/rate, a named float64 of events
+// per second
+type limit float64
+
+// parseLimit parses a rate such as "100/s" or "5/m", or "inf" for no limit
+func parseLimit(raw string) (interface{}, error) {
+	if raw == "inf" {
+		return limit(math.Inf(1)), nil
+	}
+
+	count, per, ok := strings.Cut(raw, "/")
+	if !ok {
+		return nil, fmt.Errorf("rate [%s] is not of the form count/unit", raw)
+	}
+
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := time.ParseDuration("1" + per)
+	if err != nil {
+		return nil, err
+	}
+
+	return limit(n / d.Seconds()), nil
+}
+
+func TestDecoder(t *testing.T) {
+	type Config struct {
+		VarA upper  `env:"VAR_A"`
+		VarB *upper `env:"VAR_B"`
+		VarC upper  `env:"VAR_C,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "val_a",
+		"VAR_B": "val_b",
+		"VAR_C": "dmFsX2M=",
+	})
+	p.RegisterDecoder(reflect.TypeOf(upper("")), func(raw string) (interface{}, error) {
+		return upper(strings.ToUpper(raw)), nil
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := upper("VAL_B")
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(upper("VAL_A"), config.VarA, "VarA should be decoded by the custom decoder")
+	require.Equal(&expected, config.VarB, "VarB should be decoded by the custom decoder")
+	require.Equal(upper("VAL_C"), config.VarC, "VarC should be base64-decoded before the custom decoder")
+}
+
+func TestDecoderFailure(t *testing.T) {
+	type Config struct {
+		VarA upper `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "val_a",
+	})
+	cause := fmt.Errorf("some error")
+	p.RegisterDecoder(reflect.TypeOf(upper("")), func(raw string) (interface{}, error) {
+		return nil, cause
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrDecodeFailure(cause, "VAR_A", "val_a", "decoder")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail with the decoder error")
+}
+
+func TestDecoderWrongType(t *testing.T) {
+	type Config struct {
+		VarA upper `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "val_a",
+	})
+	p.RegisterDecoder(reflect.TypeOf(upper("")), func(raw string) (interface{}, error) {
+		return raw, nil
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because the decoder returned a string")
+	_, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+}
+
+func TestDecoderIgnoredForJSON(t *testing.T) {
+	type Config struct {
+		VarA upper `env:"VAR_A,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": `"val_a"`,
+	})
+	p.RegisterDecoder(reflect.TypeOf(upper("")), func(raw string) (interface{}, error) {
+		return upper(strings.ToUpper(raw)), nil
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(upper("val_a"), config.VarA, "VarA should be decoded as JSON")
+}
+
+func TestDecoderCtx(t *testing.T) {
+	type Config struct {
+		VarA upper `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "val_a",
+	})
+	p.RegisterDecoderCtx(reflect.TypeOf(upper("")), func(ctx context.Context, raw string) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return upper(strings.ToUpper(raw)), nil
+	})
+
+	require := require.New(t)
+
+	config := Config{}
+	err := p.Get(&config)
+	require.NoError(err, "Get should not fail")
+	require.Equal(upper("VAL_A"), config.VarA, "VarA should be decoded by the custom decoder")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	config = Config{}
+	err = p.GetContext(ctx, &config)
+	require.Error(err, "GetContext should fail because the context is cancelled")
+	require.Equal(context.Canceled, errors.Cause(err), "the decoder should see the cancelled context")
+	require.Equal(upper(""), config.VarA, "VarA should not be set")
+}
+
+func TestDecoderNamedFloat(t *testing.T) {
+	type Config struct {
+		VarA limit  `env:"VAR_A"`
+		VarB *limit `env:"VAR_B,expand"`
+		VarC limit  `env:"VAR_C"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "100/s",
+		"VAR_B": "${PER_MINUTE}/m",
+		"VAR_C": "inf",
+
+		"PER_MINUTE": "6",
+	})
+	p.RegisterDecoder(reflect.TypeOf(limit(0)), parseLimit)
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := limit(0.1)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(limit(100), config.VarA, "VarA should be decoded by the custom decoder")
+	require.Equal(&expected, config.VarB, "VarB should be expanded before the custom decoder")
+	require.True(math.IsInf(float64(config.VarC), 1), "VarC should be unlimited")
+}
+
+func ExampleParser_RegisterDecoder() {
+	type Config struct {
+		Limit limit `env:"LIMIT"`
+	}
+
+	p := libconfig.NewMapParser(map[string]string{"LIMIT": "30/m"}, "env")
+	p.RegisterDecoder(reflect.TypeOf(limit(0)), parseLimit)
+
+	config := Config{}
+	err := p.Get(&config)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(config.Limit)
+	// Output: 0.5
+}
@@ -27,8 +27,12 @@
 //       fmt.Printf("DB_URL: %s\n", c.ConnectionString)
 //   }
 //
-// The field tag must begin with the environment variable name and may be followed
-// by zero or more of: base64, json, and optional.
+// The field tag must begin with the environment variable name and may be followed by
+// zero or more of: base64, hex, gzip, json, optional, poscsv, fillmissing, kv, csv,
+// prefix, oneof, fuzzy, lower, upper, indexed, numbered, prefixmap, stdin, fromfile,
+// secret, deprecated, emptyasunset, presence, intbool, expand, alt, default, char,
+// base, thousands, wrap, min, max, minlen, maxlen, kdf, salt, unit, minutes, pattern,
+// and msg.
 //
 //   type Config struct {
 //       // Basic parsing just need a name
@@ -40,6 +44,16 @@
 //       // Since it is marked as optional, IntPtr will be nil if INT_PTR is unset
 //       IntPtr *int `env:"INT_PTR,optional"`
 //
+//       // A default is used if the variable is not found, and is decoded exactly like a
+//       // value that was found, e.g. as base64 or json. Since the default may contain
+//       // commas, it must be the last option, and any option after it, e.g. optional
+//       // or msg=, is an error. Quotes in the default must be escaped.
+//       Limits map[string]int `env:"LIMITS,json,default={\"cpu\":1,\"mem\":512}"`
+//
+//       // With emptyasunset, a variable that is set to "" is treated as if it were
+//       // unset, so an optional field keeps its default and a required one is an error
+//       Region string `env:"REGION,optional,emptyasunset"`
+//
 //       // Values can be base64-encoded. Tagging with "base64" will cause libconfig to
 //       // decode the string value prior to further parsing, so you can have a base64-encoded
 //       // string, []byte, float32, etc.
@@ -54,15 +68,231 @@
 //           NestedTwo uint32 `json:"nested_two"`
 //       } `env:"JSON_STRUCT_DATA,json"`
 //
-//       // Use JSON for slices (except []byte, which can be parsed directly)
+//       // Use JSON for slices
 //       FromJSONArray []int `env:"JSON_INT_ARRAY,json"`
 //
+//       // A []byte without json is set to the raw bytes of the value, while a []byte
+//       // tagged with json is parsed as a JSON array, e.g. "[1,2,3]"
+//       RawBytes  []byte `env:"RAW_BYTES"`
+//       JSONBytes []byte `env:"JSON_BYTES,json"`
+//
+//       // A [N]byte is set to exactly N bytes, e.g. decoded from 2N hex digits with
+//       // hex, otherwise ErrWrongLength is returned
+//       RequestID [16]byte `env:"REQUEST_ID,hex"`
+//
+//       // A *regexp.Regexp is compiled from the value
+//       Matcher *regexp.Regexp `env:"MATCH"`
+//
+//       // A net.HardwareAddr is parsed as a MAC address, e.g. 01:23:45:67:89:ab
+//       MAC net.HardwareAddr `env:"MAC"`
+//
+//       // A time.Weekday or time.Month is parsed by its English name, in any case, or
+//       // by its number, e.g. Monday or 1
+//       StartDay time.Weekday `env:"START_DAY"`
+//
+//       // The nullable types of database/sql, e.g. sql.NullString, scan the value and
+//       // are only valid if it is found
+//       Replica sql.NullString `env:"REPLICA,optional"`
+//
+//       // An interface that is preset by the caller is filled by the value it holds if
+//       // that implements encoding.TextUnmarshaler
+//       Level interface{} `env:"LEVEL"`
+//
+//       // A json.RawMessage tagged with json keeps the JSON as is, but it must be valid
+//       Passthrough json.RawMessage `env:"PASSTHROUGH,json"`
+//
+//       // Use poscsv to parse a single line of CSV into the fields of a struct in
+//       // declaration order. With fillmissing, trailing columns may be omitted, in
+//       // which case those fields keep their defaults, e.g. "localhost" or "localhost,80"
+//       Addr struct {
+//           Host string
+//           Port int
+//       } `env:"ADDR,poscsv,fillmissing"`
+//
+//       // Use kv to parse comma-separated key=value pairs into the fields of a struct,
+//       // e.g. "host=localhost,port=5432". The tags of its fields name keys rather than
+//       // variables, so they are not ErrNestedTags, and may be optional, have defaults,
+//       // or be validated like variables.
+//       DB struct {
+//           Host string `env:"host"`
+//           Port int    `env:"port,min=1,default=5432"`
+//       } `env:"DB,kv"`
+//
+//       // Use csv to parse a single line of CSV into the elements of a slice. A column
+//       // containing a comma or a double quote is enclosed in double quotes, and a
+//       // double quote within it is doubled, e.g. a,"b,c","say ""hi""" has 3 elements
+//       Tags []string `env:"TAGS,csv"`
+//
+//       // A []time.Duration is parsed as CSV even without csv, e.g. 1s,2s,4s
+//       Backoffs []time.Duration `env:"BACKOFFS"`
+//
+//       // Use numbered to populate a slice from HOSTS_1, HOSTS_2, and so on, up to the
+//       // first number that is not found. With numbered=0, numbering starts at HOSTS_0.
+//       Hosts []string `env:"HOSTS,numbered"`
+//
+//       // Use prefixmap to populate a map from every variable with the prefix, keyed by
+//       // the rest of the name, e.g. FLAG_A=1 and FLAG_B=0. This requires an EnumFn to
+//       // list the variables, which Get and NewMapParser provide.
+//       Flags map[string]bool `env:"FLAG_,prefixmap"`
+//
+//       // Use lower or upper to change the case of a string after it is decoded
+//       Environment string `env:"ENVIRONMENT,lower"`
+//
+//       // Use oneof to restrict a string to a set of values separated by "|".
+//       // A default that is kept because the variable is unset must be valid too.
+//       LogLevel string `env:"LOG_LEVEL,optional,oneof=debug|info|warn|error"`
+//
+//       // With fuzzy, oneof ignores case, hyphens, and underscores and assigns the
+//       // allowed value, so "Log-Fmt" becomes "logfmt"
+//       LogFormat string `env:"LOG_FORMAT,oneof=json|logfmt,fuzzy"`
+//
+//       // Numbers can be limited to an inclusive range with min and/or max
+//       Port int `env:"PORT,min=1,max=65535"`
+//
+//       // Strings and []byte can be limited to an inclusive range of lengths with
+//       // minlen and/or maxlen. Strings are measured in runes, []byte in bytes.
+//       Token string `env:"TOKEN,minlen=32,maxlen=64"`
+//
+//       // Strings can be matched against a regular expression. Since the pattern may
+//       // contain commas, it must be the last option, and any option after it is an
+//       // error.
+//       Email string `env:"ADMIN_EMAIL,optional,pattern=^[^@]+@[^@]+$"`
+//
+//       // With msg, any error for the field includes the message, e.g. to say where
+//       // the value should come from. Like pattern, it takes the rest of the tag, so it
+//       // must be the last option and may contain commas, but not another option, e.g.
+//       // optional or default=.
+//       DBPassword string `env:"DB_PASSWORD,msg=set via vault path secret/db"`
+//
+//       // A []byte key can be derived from a passphrase and the salt in another
+//       // variable using a KDF registered in Parser.KDFs. This requires Parser.AllowKDF.
+//       Key []byte `env:"PASSPHRASE,kdf=scrypt,salt=KEY_SALT"`
+//
+//       // Use alt to fall back to other names when the variable is not found, e.g.
+//       // while renaming a variable. Alternates are tried in order and can be given as
+//       // alt=OLD|OLDER or as separate alt options. Errors use the name that was found.
+//       DatabaseURL string `env:"DATABASE_URL,alt=DB_URL|DB_CONN"`
+//
+//       // Integers are parsed in base 10 unless the base is given. With base=0, the base
+//       // is implied by a Go-style prefix: 0x for hex, 0o or 0 for octal, and 0b for binary.
+//       Mask uint32 `env:"MASK,base=16"`
+//       Mode int    `env:"MODE,base=0"`
+//
+//       // With thousands, a separator such as _ or ' is removed from a number before it
+//       // is parsed. A comma, which separates the options, is written as comma. Base 0
+//       // also accepts Go-style underscores, e.g. 1_000_000.
+//       Max int `env:"MAX,thousands=comma"`
+//
+//       // With wrap, an integer that does not fit is truncated to the width of the
+//       // field, like a C cast, rather than being an ErrOverflow, e.g. 300 becomes 44
+//       Low uint8 `env:"LOW,wrap"`
+//
+//       // With char, an integer such as a rune or byte is set to the code point of a
+//       // value that must be exactly one character, e.g. "," or "é"
+//       Separator rune `env:"SEPARATOR,char"`
+//
+//       // With expand, ${VAR} and $VAR references are replaced with the values of those
+//       // variables (as found by the LookupFn, without the Parser's Prefix) before any
+//       // decoding, e.g. "https://${HOST}:${PORT}". References are expanded recursively.
+//       // A missing variable expands to "", or is an error with expand=strict.
+//       URL string `env:"URL,expand=strict"`
+//
+//       // Use unit for human-readable values. The conversion depends on the type: a
+//       // time.Duration accepts "1h30m" or "01:30:00", and any other integer accepts
+//       // a size in bytes such as "512", "10MB", or "1.5GiB" (KB is 1000, KiB is 1024)
+//       Timeout   time.Duration `env:"TIMEOUT,unit"`
+//       MaxUpload int64         `env:"MAX_UPLOAD,unit"`
+//
+//       // A time.Duration tagged with unit=<unit>, where the unit is one of ns, us, ms, s,
+//       // m, or h, also accepts a bare number of that unit, e.g. "30" means 30s, while
+//       // "30s" or "1m" are parsed as usual
+//       Interval time.Duration `env:"INTERVAL,unit=s"`
+//
+//       // Use minutes to store a duration in a plain integer as whole minutes, e.g.
+//       // "1h30m" is 90. A remainder such as the 30s of "90s" is an error, unless the
+//       // tag has minutes=round, which rounds down to whole minutes.
+//       RetentionMinutes int `env:"RETENTION,minutes"`
+//
+//       // For a string or []byte tagged with stdin, the value "-" means read the value
+//       // from the Parser's Stdin (os.Stdin by default)
+//       Input []byte `env:"INPUT,stdin"`
+//
+//       // With presence, a bool is a flag that is true if the variable is set at all,
+//       // even to "", and false otherwise
+//       Verbose bool `env:"VERBOSE,presence"`
+//
+//       // With intbool, a bool is parsed as an integer that is true unless it is zero,
+//       // e.g. "2" is true and "0" is false
+//       Trace bool `env:"TRACE,intbool"`
+//
+//       // With fromfile, the value is the path of a file holding the real value, as in
+//       // the _FILE convention for Docker and Kubernetes secrets. A trailing newline is
+//       // removed, and ErrFileRead is returned if the file cannot be read.
+//       Password string `env:"PASSWORD_FILE,fromfile"`
+//
+//       // A []byte without base64 or hex holds the exact contents of the file, e.g. a
+//       // binary key or certificate
+//       Cert []byte `env:"TLS_CERT,fromfile"`
+//
+//       // If a variable tagged with deprecated is set, the Parser's OnDeprecated is
+//       // called with its name, e.g. to warn that it is being phased out
+//       LegacyMode bool `env:"LEGACY_MODE,optional,deprecated"`
+//
+//       // Fields tagged with secret are redacted by Dump
+//       APIKey string `env:"API_KEY,secret"`
+//
+//       // Untagged structs, embedded or not, are parsed and their tagged fields are
+//       // treated as if they belonged to the parent
+//       Embedded
+//
+//       // A nil pointer to a struct is allocated only if at least one of its fields is
+//       // set, so a fully-absent optional section stays nil
+//       TLS *TLSConfig
+//
+//       // Structs tagged with prefix are parsed too, but the tag name is prepended to
+//       // the names of their fields, e.g. DB_HOST. Prefixes of nested structs accumulate.
+//       Database `env:"DB_,prefix"`
+//
+//       // A slice of structs tagged with indexed is populated from numbered sets of
+//       // variables, e.g. SERVER_0_HOST, SERVER_0_PORT, SERVER_1_HOST, and so on. An
+//       // element exists if any of its variables is found, and the slice ends at the
+//       // first index with none, so a gap ends the slice. If there are no elements, the
+//       // slice keeps its default.
+//       Servers []Server `env:"SERVER,indexed"`
+//
+//       // Any other tagged struct is populated from its own variable (typically as
+//       // json) and must not contain tagged fields, unless Parser.AllowNestedTags is
+//       // set, in which case the tags of its fields are ignored
+//       Server Server `env:"SERVER,json"`
+//
+//       // The sync/atomic types Int32, Int64, Uint32, Uint64, and Bool are set by
+//       // storing the parsed value, so they can be loaded safely while being reloaded
+//       Debug atomic.Bool `env:"DEBUG"`
+//
+//       // Maps without json are parsed from comma-separated key=value pairs, e.g.
+//       // "a=1s,b=2m". Each value is parsed with the custom decoder registered for its
+//       // type, if any, and a time.Duration accepts the same values as with unit.
+//       Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+//
+//       // A type that libconfig cannot otherwise set, such as a struct or map, is
+//       // decoded with its UnmarshalJSON method if it implements json.Unmarshaler,
+//       // even without the json option
+//       Point Point `env:"POINT"`
+//
 //       // Base64 and JSON can be used together
 //       FromB64JSON string `env:"B64_JSON,base64,json"`
 //
 //       // Tag ordering does not matter, base64-decoding happens first if-specified
 //       // (since that's the only reasonable option)
 //       FromB64JSONAlso string `env:"B64_JSON,json,base64"`
+//
+//       // A [][]byte tagged with base64 (but not json) is a comma-separated list of
+//       // base64 items, e.g. "YQ==,Yg==,Yw=="
+//       Blobs [][]byte `env:"BLOBS,base64"`
+//
+//       // Large values can be gzipped before being base64-encoded. Decoding happens in
+//       // the order base64, gzip, and then json.
+//       FromB64GzipJSON []string `env:"B64_GZIP_JSON,base64,gzip,json"`
 //   }
 //
 // To use a different tag name, instead of the default of "env", create a Parser.
@@ -74,4 +304,184 @@
 //
 //   err := p.Get(&config)
 //
+// To migrate between libraries, Tags lists several tag names in order of precedence.
+// The first of them that is present on a field is used.
+//
+//   p.Tags = []string{"env", "envconfig"}
+//
+// ChainLookup combines lookup functions in order of precedence, and FlagLookup looks up
+// the flags that were set on the command line, matching LOG_LEVEL to log-level.
+//
+//   p.LookupFn = libconfig.ChainLookup(libconfig.FlagLookup(flag.CommandLine), os.LookupEnv)
+//
+// NewMapParser creates a Parser that reads from a map instead of the environment,
+// which is handy in tests.
+//
+//   p := libconfig.NewMapParser(map[string]string{"CONN_STRING": "..."}, "env")
+//
+// To force a single variable, With returns a copy of a Parser that finds the given
+// value for the name and looks up every other name as before.
+//
+//   err := p.With("LOG_LEVEL", "debug").Get(&config)
+//
+// With AutoName, a Parser derives omitted names from the field names, e.g. MaxConns
+// becomes MAX_CONNS, and NameFunc can replace the conversion.
+//
+//   type Config struct {
+//       MaxConns int `env:",optional"`
+//   }
+//
+// A Parser can also prepend a Prefix to every variable name and, given an EnumFn that
+// lists the available variables, report the prefixed variables that no field consumes.
+//
+//   p := libconfig.Parser{
+//       Tag:      "env",
+//       Prefix:   "MYAPP_",
+//       LookupFn: os.LookupEnv,
+//       EnumFn:   func() []string { ... },
+//   }
+//
+//   unused, err := p.UnusedVars(&config)
+//
+// For platform quirks, an AliasFn maps each name, after the Prefix is applied, to the
+// name that is actually looked up. Errors still refer to the name before mapping.
+//
+//   p.AliasFn = func(name string) string { return "PLATFORM_" + name }
+//
+// Check validates the tags of a config without looking up any variables, so a typo such
+// as `optinal` can fail in a unit test rather than in production. Like the other
+// methods that only read the type of the config, e.g. Template and UnusedVars, it
+// accepts a struct as well as a pointer to one, while Get requires a pointer.
+//
+//   func TestConfigTags(t *testing.T) {
+//       if err := libconfig.Check(Config{}); err != nil {
+//           t.Fatal(err)
+//       }
+//   }
+//
+// GetStrict is like Get, but fails with an ErrUntaggedField if an exported field that is
+// not a struct has no tag, e.g. because its tag was forgotten.
+//
+//   err := p.GetStrict(&config)
+//
+// Template produces a sample env file for onboarding, with a comment for each variable
+// saying whether it is required and its type.
+//
+//   sample, err := p.Template(&config)
+//
+// Dump is the inverse of Get: it returns the variables that would populate the current
+// values of the config, e.g. to write back an env file, with secret fields redacted.
+//
+//   values, err := p.Dump(&config) // e.g. {"MYAPP_DB_HOST": "localhost"}
+//
+// GetSources reports the name of the variable that was found for each field, after
+// applying prefixes and alternate names, without parsing the values.
+//
+//   sources, err := p.GetSources(&config) // e.g. {"Database.Host": "MYAPP_DB_HOST"}
+//
+// Frameworks that already hold a reflect.Value for the config can pass it to ParseValue
+// instead, as long as the struct is addressable.
+//
+// Latency-sensitive programs can Prepare the config type once, after which Get executes
+// a precomputed plan for it rather than walking the struct, with identical results.
+//
+//   err := p.Prepare(&config)
+//
+// With ResolveRefs, a value that is exactly $OTHER_VAR is replaced by the value of
+// OTHER_VAR, one level deep, e.g. DATABASE_URL=$DB_URL.
+//
+//   p.ResolveRefs = true
+//
+// A Parser's DefaultFn provides baseline values for variables that are not found, taking
+// precedence over any default in the tag, and OnSet reports the Source of each value.
+//
+//   p.DefaultFn = configServer.Lookup
+//   p.OnSet = func(name string, source libconfig.Source) {
+//       log.Printf("%s set from %s", name, source)
+//   }
+//
+// GetResolved reports the same for every field in one map, keyed by the primary name
+// of each variable.
+//
+//   resolved, err := p.GetResolved(&config) // e.g. {"PORT": {"PORT_NUMBER", "alt"}}
+//
+// For troubleshooting, a Logger receives a narrative of each field as Get parses it,
+// with secrets redacted, e.g. a *log.Logger wrapped to provide Debugf.
+//
+// An expensive default for a required variable can be computed lazily, only if the
+// variable is missing and has no other default.
+//
+//   p.RegisterLazyDefault("API_TOKEN", func() (string, error) {
+//       return vault.Fetch("api-token")
+//   })
+//
+// Rather than supplying defaults field by field, a Parser can copy a whole defaults
+// struct into the config before parsing. Optional variables that are not found keep
+// their default.
+//
+//   err := p.GetWithDefaults(&config, defaults)
+//
+// With PreserveOnJSONNull, a json field whose value is exactly null keeps its default
+// too, rather than being zeroed.
+//
+//   p.PreserveOnJSONNull = true
+//
+// The fields of a struct decoded from json cannot be tagged as variables, so a type
+// that must check its own invariants, e.g. that a required field is set, can implement
+// Validator. After decoding a json field, or one with a registered encoding, Get calls
+// Validate on the value and on each element of a slice, array, or map, and wraps any
+// failure in an ErrValidation naming the element, e.g. SERVERS[1].
+//
+//   func (s Server) Validate() error {
+//       if s.Host == "" {
+//           return errors.New("host is required")
+//       }
+//       return nil
+//   }
+//
+// Values tagged with base64 must be padded standard base64 unless the Parser has
+// LenientBase64, which also accepts unpadded and URL-safe base64 as produced by many
+// tools.
+//
+//   p.LenientBase64 = true
+//
+// Other encodings can be added as tag options with RegisterUnmarshaler. For example,
+// importing github.com/jrudder/libconfig/yaml adds the yaml option, which works like
+// json and can likewise be combined with base64, and importing
+// github.com/jrudder/libconfig/toml adds the toml option.
+//
+// Every error returned by this package has an ErrorCode method that returns a stable
+// code, e.g. "var_not_found", and a MarshalJSON method that encodes the code, the
+// message, the details, and any cause as a JSON object for tooling. Errors that wrap a
+// cause support errors.Is and errors.As, and errors.Is(err, libconfig.ErrNotFound)
+// reports whether a variable was not found. An error for a field of a nested struct is
+// wrapped in an ErrField with the path of the field, e.g. Database.Primary.Host.
+//
+// With CollectErrors, Get continues past fields that fail and returns an ErrMultiple
+// holding every error. The errors are always in the order of the fields: declaration
+// order, depth-first through nested structs and the elements of indexed slices.
+//
+//   p.CollectErrors = true
+//   err := p.Get(&config) // e.g. 2 errors: var not found for key [HOST]; ...
+//
+// Custom decoders can be registered for types that libconfig cannot parse itself.
+// A context-aware decoder receives the context given to GetContext (Get passes
+// context.Background()), so slow decoders can honor cancellation.
+//
+//   p.RegisterDecoder(reflect.TypeOf(Level(0)), func(raw string) (interface{}, error) {
+//       return ParseLevel(raw)
+//   })
+//
+//   p.RegisterDecoderCtx(reflect.TypeOf(Secret{}), func(ctx context.Context, raw string) (interface{}, error) {
+//       return vault.Fetch(ctx, raw)
+//   })
+//
+//   err := p.GetContext(ctx, &config)
+//
+// If looking up a variable can fail, e.g. because it is fetched from a remote secrets
+// store, set LookupFn2 instead of LookupFn, or LookupCtxFn to also receive the context
+// given to GetContext. Any error they return is wrapped in ErrLookupFailed rather than
+// being mistaken for a missing variable. GetContext also stops before the next field
+// once the context is done.
+//
 package libconfig
@@ -0,0 +1,432 @@
+package libconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redacted is the value that Dump writes in place of a field tagged as secret
+const Redacted = "[REDACTED]"
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Dump returns the variables that would populate the current values of the config,
+// i.e. the inverse of Get, keyed by their names including any prefix. Values are
+// written with MarshalText if the type implements encoding.TextMarshaler and are
+// otherwise formatted to be parsed by the same tag, e.g. base64 fields are encoded and
+// json fields are marshaled. Fields tagged as secret are written as Redacted, and nil
+// pointers are omitted. The config must be a pointer to a struct and is not modified.
+//
+// Values that cannot be reversed, e.g. those derived with kdf or read with fromfile,
+// result in an ErrCannotDump.
+func (p *Parser) Dump(config interface{}) (map[string]string, error) {
+	v := reflect.ValueOf(config)
+	if !(v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct) {
+		return nil, NewErrInvalidConfigType(v.Type())
+	}
+
+	values := map[string]string{}
+	err := p.dump(v.Elem(), p.Prefix, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// dump adds the variables for each field of the struct to the map, following the same
+// rules for nested structs as parse
+func (p *Parser) dump(v reflect.Value, prefix string, values map[string]string) error {
+	fields, err := p.fields(v.Type(), prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if f.Field.PkgPath != "" {
+			continue
+		}
+		field := v.Field(f.Index)
+
+		tag := f.Tag
+		if tag.Indexed {
+			for i := 0; i < field.Len(); i++ {
+				err = p.dump(field.Index(i), indexedPrefix(tag.Name, i), values)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if tag.Numbered {
+			for i := 0; i < field.Len(); i++ {
+				elemTag := tag
+				elemTag.Name = numberedName(tag.Name, tag.NumberFrom+i)
+				err = dumpValue(field.Index(i), elemTag, values)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if tag.PrefixMap {
+			err = dumpPrefixMap(field, tag, values)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tag.Tagged && !tag.Prefix {
+			err = dumpValue(field, tag, values)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		nestedPrefix := prefix
+		if tag.Prefix {
+			nestedPrefix = tag.Name
+		}
+
+		if f.IsStruct {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+
+			err = p.dump(field, nestedPrefix, values)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dumpValue adds the variable for a single tagged value to the map, omitting it if the
+// value is a nil pointer
+func dumpValue(v reflect.Value, tag tagData, values map[string]string) error {
+	if tag.Secret {
+		values[tag.Name] = Redacted
+		return nil
+	}
+
+	if tag.KDF != "" || tag.FromFile || tag.PosCSV || tag.Unmarshaler != "" {
+		return NewErrCannotDump(tag.Name, v.Type())
+	}
+
+	v, ok := indirect(v)
+	if !ok {
+		return nil
+	}
+
+	// A flag is true if it is present at all, so it is omitted if false
+	if tag.Presence {
+		if v.Bool() {
+			values[tag.Name] = "true"
+		}
+		return nil
+	}
+
+	if isBytesList(v.Type()) && tag.Base64 {
+		items := make([]string, v.Len())
+		for i := range items {
+			data, err := compress(v.Index(i).Bytes(), tag)
+			if err != nil {
+				return err
+			}
+			items[i] = base64.StdEncoding.EncodeToString(data)
+		}
+		values[tag.Name] = strings.Join(items, ",")
+		return nil
+	}
+
+	data, err := formatValue(v, tag)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case tag.Base64:
+		data, err = compress(data, tag)
+		if err != nil {
+			return err
+		}
+		values[tag.Name] = base64.StdEncoding.EncodeToString(data)
+	case tag.Hex:
+		values[tag.Name] = hex.EncodeToString(data)
+	default:
+		values[tag.Name] = string(data)
+	}
+
+	return nil
+}
+
+// dumpPrefixMap adds a variable for each entry of a map tagged with prefixmap, named
+// by appending the key to the prefix
+func dumpPrefixMap(v reflect.Value, tag tagData, values map[string]string) error {
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := formatValue(iter.Key(), tagData{Name: tag.Name})
+		if err != nil {
+			return err
+		}
+
+		elemTag := tag
+		elemTag.Name = tag.Name + string(key)
+
+		// Elements that are durations are always parsed as such
+		if derefType(v.Type().Elem()) == durationType {
+			elemTag.Unit = true
+		}
+
+		err = dumpValue(iter.Value(), elemTag, values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indirect dereferences any pointers other than a *regexp.Regexp, which is formatted
+// by its pattern. It returns false if a pointer is nil.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr && v.Type() != regexpType {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+
+	return v, !(v.Kind() == reflect.Ptr && v.IsNil())
+}
+
+// compress gzips the data if the tag has the gzip option
+func compress(data []byte, tag tagData) ([]byte, error) {
+	if !tag.Gzip {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatValue formats the value so that setting it with the same tag restores it
+func formatValue(v reflect.Value, tag tagData) ([]byte, error) {
+	if tag.JSON {
+		return json.Marshal(v.Interface())
+	}
+
+	if m, ok := textMarshaler(v); ok {
+		return m.MarshalText()
+	}
+
+	// Atomic wrappers are formatted by their loaded value
+	if _, ok := atomicTypes[v.Type()]; ok && v.CanAddr() {
+		loaded := v.Addr().MethodByName("Load").Call(nil)[0]
+		return formatValue(loaded, tag)
+	}
+
+	if isHardwareAddr(v.Type(), tag) {
+		return []byte(v.Interface().(net.HardwareAddr).String()), nil
+	}
+
+	if v.Type() == regexpType {
+		return []byte(v.Interface().(*regexp.Regexp).String()), nil
+	}
+
+	// A duration with a unit must include it, since a bare number would be in the unit
+	if v.Type() == durationType && tag.Unit {
+		return []byte(time.Duration(v.Int()).String()), nil
+	}
+
+	// A number of minutes is written as a duration, e.g. 1h30m0s
+	if tag.Minutes {
+		return []byte((time.Duration(v.Int()) * time.Minute).String()), nil
+	}
+
+	k := v.Kind()
+	if tag.Char && isInt(k) {
+		return []byte(string(rune(v.Int()))), nil
+	}
+	if tag.Char && isUint(k) {
+		return []byte(string(rune(v.Uint()))), nil
+	}
+
+	switch k {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Bool:
+		if tag.IntBool {
+			if v.Bool() {
+				return []byte("1"), nil
+			}
+			return []byte("0"), nil
+		}
+		return []byte(strconv.FormatBool(v.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []byte(formatInt(v.Int(), tag.base())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []byte(formatUint(v.Uint(), tag.base())), nil
+	case reflect.Float32, reflect.Float64:
+		return []byte(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+		if tag.CSV {
+			return formatCSVList(v, tag)
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			return data, nil
+		}
+	case reflect.Map:
+		if isMap(v) {
+			return formatMap(v, tag)
+		}
+	}
+
+	return nil, NewErrCannotDump(tag.Name, v.Type())
+}
+
+// textMarshaler returns the value as an encoding.TextMarshaler if either it or a
+// pointer to it implements the interface
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		return v.Addr().Interface().(encoding.TextMarshaler), true
+	}
+
+	return nil, false
+}
+
+// formatInt formats the integer in the base, using base 10 if the base is implied by
+// a prefix when parsing
+func formatInt(i int64, base int) string {
+	if base == 0 {
+		base = 10
+	}
+
+	return strconv.FormatInt(i, base)
+}
+
+// formatUint formats the unsigned integer in the base, like formatInt
+func formatUint(u uint64, base int) string {
+	if base == 0 {
+		base = 10
+	}
+
+	return strconv.FormatUint(u, base)
+}
+
+// formatCSVList formats each element of the slice and writes them as a single CSV
+// record, the inverse of parseCSVList
+func formatCSVList(v reflect.Value, tag tagData) ([]byte, error) {
+	columns := make([]string, v.Len())
+	for i := range columns {
+		elemTag := tag
+		elemTag.Name = tag.Name + "[" + strconv.Itoa(i) + "]"
+
+		elem, ok := indirect(v.Index(i))
+		if !ok {
+			return nil, NewErrCannotDump(elemTag.Name, v.Type().Elem())
+		}
+
+		// Elements that are durations are always parsed as such
+		if elem.Type() == durationType {
+			elemTag.Unit = true
+		}
+
+		data, err := formatValue(elem, elemTag)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = string(data)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	err := w.Write(columns)
+	if err == nil {
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// formatMap formats the map as comma-separated key=value pairs sorted by key, the
+// inverse of parseMap
+func formatMap(v reflect.Value, tag tagData) ([]byte, error) {
+	entries := make([]string, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := formatValue(iter.Key(), tagData{Name: tag.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		elemTag := tagData{Name: fmt.Sprintf("%s[%s]", tag.Name, key)}
+		elem, ok := indirect(iter.Value())
+		if !ok {
+			return nil, NewErrCannotDump(elemTag.Name, v.Type().Elem())
+		}
+
+		// Map values that are durations are always parsed as such
+		if elem.Type() == durationType {
+			elemTag.Unit = true
+		}
+
+		data, err := formatValue(elem, elemTag)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, string(key)+"="+string(data))
+	}
+
+	sort.Strings(entries)
+	return []byte(strings.Join(entries, ",")), nil
+}
@@ -0,0 +1,171 @@
+package libconfig_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestDumpRoundTrip(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+		Port uint16 `env:"PORT"`
+	}
+	type Server struct {
+		Addr net.IP `env:"ADDR"`
+	}
+	type Config struct {
+		Name     string            `env:"NAME"`
+		Debug    bool              `env:"DEBUG"`
+		Ratio    float64           `env:"RATIO"`
+		Mode     int               `env:"MODE,base=8"`
+		Timeout  time.Duration     `env:"TIMEOUT,unit=s"`
+		Key      []byte            `env:"KEY,base64,gzip"`
+		ID       [4]byte           `env:"ID,hex"`
+		Tags     []string          `env:"TAGS,csv"`
+		Hosts    []string          `env:"HOST,numbered"`
+		Limits   map[string]int    `env:"LIMITS"`
+		Labels   map[string]string `env:"LABELS,json"`
+		Retries  *int              `env:"RETRIES,optional"`
+		Database `env:"DB_,prefix"`
+		Servers  []Server `env:"SERVER,indexed"`
+	}
+
+	expected := Config{
+		Name:     "app",
+		Debug:    true,
+		Ratio:    0.25,
+		Mode:     0755,
+		Timeout:  90 * time.Second,
+		Key:      []byte("secret key"),
+		ID:       [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Tags:     []string{"a", "b,c"},
+		Hosts:    []string{"one", "two"},
+		Limits:   map[string]int{"x": 1, "y": 2},
+		Labels:   map[string]string{"team": "core"},
+		Database: Database{Host: "db.local", Port: 5432},
+		Servers:  []Server{{Addr: net.ParseIP("10.0.0.1")}, {Addr: net.ParseIP("10.0.0.2")}},
+	}
+
+	p := mapToParser(nil)
+	p.Prefix = "APP_"
+	values, err := p.Dump(&expected)
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal("755", values["APP_MODE"], "Mode should be written in its base")
+	require.Equal("1m30s", values["APP_TIMEOUT"], "Timeout should include its unit")
+	require.Equal("deadbeef", values["APP_ID"], "ID should be hex-encoded")
+	require.Equal(`a,"b,c"`, values["APP_TAGS"], "Tags should be quoted as CSV")
+	require.Equal("x=1,y=2", values["APP_LIMITS"], "Limits should be sorted key=value pairs")
+	require.Equal("10.0.0.2", values["APP_SERVER_1_ADDR"], "Addr should use MarshalText")
+	require.NotContains(values, "APP_RETRIES", "nil pointers should be omitted")
+
+	p = mapToParser(values)
+	p.Prefix = "APP_"
+	p.RegisterDecoder(reflect.TypeOf(net.IP{}), func(raw string) (interface{}, error) {
+		return net.ParseIP(raw), nil
+	})
+
+	var actual Config
+	err = p.Get(&actual)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, actual, "the dumped values should parse to the same config")
+}
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	type Config struct {
+		User     string `env:"USER"`
+		Password string `env:"PASSWORD,secret"`
+	}
+
+	p := mapToParser(nil)
+	values, err := p.Dump(&Config{User: "admin", Password: "hunter2"})
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal(map[string]string{
+		"USER":     "admin",
+		"PASSWORD": libconfig.Redacted,
+	}, values, "the secret should be redacted")
+}
+
+func TestDumpDerivedKey(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"KEY,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(nil)
+	_, err := p.Dump(&Config{Key: []byte("derived")})
+
+	expected := libconfig.NewErrCannotDump("KEY", reflect.TypeOf([]byte{}))
+	require.Equal(t, expected, err, "a derived key cannot be dumped")
+}
+
+func TestDumpInvalidConfigType(t *testing.T) {
+	config := struct{}{}
+	p := mapToParser(nil)
+	_, err := p.Dump(config)
+
+	expected := libconfig.NewErrInvalidConfigType(reflect.TypeOf(config))
+	require.Equal(t, expected, err, "the config must be a pointer to a struct")
+}
+
+func TestDumpPresence(t *testing.T) {
+	type Config struct {
+		Enabled  bool `env:"ENABLED,presence"`
+		Disabled bool `env:"DISABLED,presence"`
+	}
+
+	p := mapToParser(nil)
+	values, err := p.Dump(&Config{Enabled: true})
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal(map[string]string{"ENABLED": "true"}, values, "a false flag should be omitted so that it stays false")
+}
+
+func TestDumpDurationList(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration `env:"BACKOFFS"`
+	}
+
+	p := mapToParser(nil)
+	values, err := p.Dump(&Config{Backoffs: []time.Duration{time.Second, 90 * time.Minute}})
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal(map[string]string{"BACKOFFS": "1s,1h30m0s"}, values, "each duration should include its unit")
+}
+
+func TestDumpMinutes(t *testing.T) {
+	type Config struct {
+		Interval int `env:"INTERVAL,minutes"`
+	}
+
+	p := mapToParser(nil)
+	values, err := p.Dump(&Config{Interval: 90})
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal(map[string]string{"INTERVAL": "1h30m0s"}, values, "the minutes should be written as a duration")
+}
+
+func TestDumpIntBool(t *testing.T) {
+	type Config struct {
+		Enabled  bool `env:"ENABLED,intbool"`
+		Disabled bool `env:"DISABLED,intbool"`
+	}
+
+	p := mapToParser(nil)
+	values, err := p.Dump(&Config{Enabled: true})
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal(map[string]string{"ENABLED": "1", "DISABLED": "0"}, values, "the flags should be written as integers")
+}
@@ -0,0 +1,357 @@
+package libconfig
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// codedError is implemented by every error type in this package. The code is stable
+// and suitable for tools to switch on, unlike the human-readable message.
+type codedError interface {
+	error
+	ErrorCode() string
+}
+
+// marshalError encodes an error as a JSON object with its code, its message, the given
+// fields, and, if not nil, the error that caused it. A cause that is itself one of this
+// package's errors is encoded as a nested object, while any other cause is encoded as
+// its message.
+func marshalError(e codedError, fields map[string]interface{}, because error) ([]byte, error) {
+	fields["code"] = e.ErrorCode()
+	fields["message"] = e.Error()
+
+	if because != nil {
+		if m, ok := because.(json.Marshaler); ok {
+			fields["because"] = m
+		} else {
+			fields["because"] = because.Error()
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// ErrorCode returns "already_set"
+func (e *ErrAlreadySet) ErrorCode() string { return "already_set" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrAlreadySet) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+	}, nil)
+}
+
+// ErrorCode returns "cannot_dump"
+func (e *ErrCannotDump) ErrorCode() string { return "cannot_dump" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrCannotDump) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":  e.Key,
+		"type": fmt.Sprint(e.Type),
+	}, nil)
+}
+
+// ErrorCode returns "cannot_parse_env"
+func (e *ErrCannotParseEnv) ErrorCode() string { return "cannot_parse_env" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrCannotParseEnv) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"kind":  e.Kind.String(),
+		"key":   e.Key,
+		"value": e.Value,
+	}, e.Because)
+}
+
+// ErrorCode returns "cannot_set_kind"
+func (e *ErrCannotSetKind) ErrorCode() string { return "cannot_set_kind" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrCannotSetKind) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"kind": e.Kind.String(),
+	}, nil)
+}
+
+// ErrorCode returns "conflicting_options"
+func (e *ErrConflictingOptions) ErrorCode() string { return "conflicting_options" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrConflictingOptions) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"tag":     e.Tag,
+		"options": e.Options,
+	}, nil)
+}
+
+// ErrorCode returns "decode_failure"
+func (e *ErrDecodeFailure) ErrorCode() string { return "decode_failure" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrDecodeFailure) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":   e.Key,
+		"value": e.Value,
+		"type":  e.Type,
+	}, e.Because)
+}
+
+// ErrorCode returns "default_failed"
+func (e *ErrDefaultFailed) ErrorCode() string { return "default_failed" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrDefaultFailed) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+	}, e.Because)
+}
+
+// ErrorCode returns "defaults_type_mismatch"
+func (e *ErrDefaultsTypeMismatch) ErrorCode() string { return "defaults_type_mismatch" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrDefaultsTypeMismatch) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"config":   fmt.Sprint(e.Config),
+		"defaults": fmt.Sprint(e.Defaults),
+	}, nil)
+}
+
+// ErrorCode returns "field"
+func (e *ErrField) ErrorCode() string { return "field" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrField) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"path": e.Path,
+	}, e.Because)
+}
+
+// ErrorCode returns "file_read"
+func (e *ErrFileRead) ErrorCode() string { return "file_read" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrFileRead) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":  e.Key,
+		"path": e.Path,
+	}, e.Because)
+}
+
+// ErrorCode returns "invalid_config_type"
+func (e *ErrInvalidConfigType) ErrorCode() string { return "invalid_config_type" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrInvalidConfigType) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"type": fmt.Sprint(e.Type),
+	}, nil)
+}
+
+// ErrorCode returns "invalid_pattern"
+func (e *ErrInvalidPattern) ErrorCode() string { return "invalid_pattern" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrInvalidPattern) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"pattern": e.Pattern,
+	}, e.Because)
+}
+
+// ErrorCode returns "invalid_tag_option"
+func (e *ErrInvalidTagOption) ErrorCode() string { return "invalid_tag_option" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrInvalidTagOption) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"tag":    e.Tag,
+		"option": e.BadOption,
+	}, nil)
+}
+
+// ErrorCode returns "length_violation"
+func (e *ErrLengthViolation) ErrorCode() string { return "length_violation" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrLengthViolation) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+		"len": e.Len,
+		"min": e.Min,
+		"max": e.Max,
+	}, nil)
+}
+
+// ErrorCode returns "lookup_failed"
+func (e *ErrLookupFailed) ErrorCode() string { return "lookup_failed" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrLookupFailed) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+	}, e.Because)
+}
+
+// ErrorCode returns "missing_name_tag"
+func (e *ErrMissingNameTag) ErrorCode() string { return "missing_name_tag" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrMissingNameTag) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"tag": e.Tag,
+	}, nil)
+}
+
+// ErrorCode returns "multiple"
+func (e *ErrMultiple) ErrorCode() string { return "multiple" }
+
+// MarshalJSON encodes the error for tools, with each error encoded like a cause
+func (e *ErrMultiple) MarshalJSON() ([]byte, error) {
+	errs := make([]interface{}, len(e.Errors))
+	for i, err := range e.Errors {
+		if m, ok := err.(json.Marshaler); ok {
+			errs[i] = m
+		} else {
+			errs[i] = err.Error()
+		}
+	}
+
+	return marshalError(e, map[string]interface{}{
+		"errors": errs,
+	}, nil)
+}
+
+// ErrorCode returns "namespace"
+func (e *ErrNamespace) ErrorCode() string { return "namespace" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrNamespace) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"namespace": e.Namespace,
+	}, e.Because)
+}
+
+// ErrorCode returns "not_addressable"
+func (e *ErrNotAddressable) ErrorCode() string { return "not_addressable" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrNotAddressable) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"type": fmt.Sprint(e.Type),
+	}, nil)
+}
+
+// ErrorCode returns "not_in_enum"
+func (e *ErrNotInEnum) ErrorCode() string { return "not_in_enum" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrNotInEnum) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":     e.Key,
+		"value":   e.Value,
+		"allowed": e.Allowed,
+	}, nil)
+}
+
+// ErrorCode returns "out_of_range"
+func (e *ErrOutOfRange) ErrorCode() string { return "out_of_range" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrOutOfRange) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":   e.Key,
+		"value": e.Value,
+		"min":   e.Min,
+		"max":   e.Max,
+	}, nil)
+}
+
+// ErrorCode returns "overflow"
+func (e *ErrOverflow) ErrorCode() string { return "overflow" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrOverflow) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"kind":  e.Kind.String(),
+		"key":   e.Key,
+		"value": e.Value,
+	}, nil)
+}
+
+// ErrorCode returns "pattern_mismatch"
+func (e *ErrPatternMismatch) ErrorCode() string { return "pattern_mismatch" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrPatternMismatch) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":     e.Key,
+		"value":   e.Value,
+		"pattern": e.Pattern,
+	}, nil)
+}
+
+// ErrorCode returns "untagged_field"
+func (e *ErrUntaggedField) ErrorCode() string { return "untagged_field" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrUntaggedField) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"name": e.Name,
+	}, nil)
+}
+
+// ErrorCode returns "validation"
+func (e *ErrValidation) ErrorCode() string { return "validation" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrValidation) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+	}, e.Because)
+}
+
+// ErrorCode returns "var_not_found"
+func (e *ErrVarNotFound) ErrorCode() string { return "var_not_found" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrVarNotFound) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+	}, nil)
+}
+
+// ErrorCode returns "with_message"
+func (e *ErrWithMessage) ErrorCode() string { return "with_message" }
+
+// MarshalJSON encodes the error for tools. The message from the tag is msg, since
+// message holds the whole description of the error.
+func (e *ErrWithMessage) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key": e.Key,
+		"msg": e.Message,
+	}, e.Because)
+}
+
+// ErrorCode returns "wrong_length"
+func (e *ErrWrongLength) ErrorCode() string { return "wrong_length" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrWrongLength) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"key":      e.Key,
+		"expected": e.Expected,
+		"actual":   e.Actual,
+	}, nil)
+}
+
+// ErrorCode returns "nested_tags"
+func (e *ErrNestedTags) ErrorCode() string { return "nested_tags" }
+
+// MarshalJSON encodes the error for tools
+func (e *ErrNestedTags) MarshalJSON() ([]byte, error) {
+	return marshalError(e, map[string]interface{}{
+		"field": e.Field,
+		"key":   e.Key,
+	}, nil)
+}
@@ -1,10 +1,50 @@
 package libconfig
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
+// ErrAlreadySet is returned if a Parser has ErrorOnPreset and a required field already
+// has a non-zero value before it is parsed
+type ErrAlreadySet struct {
+	Key string
+}
+
+// NewErrAlreadySet creates an ErrAlreadySet
+func NewErrAlreadySet(key string) *ErrAlreadySet {
+	return &ErrAlreadySet{
+		Key: key,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrAlreadySet) Error() string {
+	return fmt.Sprintf("field for key [%s] is already set", e.Key)
+}
+
+// ErrCannotDump is returned by Dump if the value of a field cannot be written back as
+// a variable, e.g. because it was derived with kdf or decoded by a registered unmarshaler
+type ErrCannotDump struct {
+	Key  string
+	Type reflect.Type
+}
+
+// NewErrCannotDump creates an ErrCannotDump error
+func NewErrCannotDump(key string, t reflect.Type) *ErrCannotDump {
+	return &ErrCannotDump{
+		Key:  key,
+		Type: t,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrCannotDump) Error() string {
+	return fmt.Sprintf("cannot dump value of type [%s] for key [%s]", e.Type, e.Key)
+}
+
 // ErrCannotParseEnv is returned if the variable cannot be parsed into the type
 // expected by the struct field, e.g. parsing "500" into int8 will return this.
 // This indicates that either the struct field is the wrong type or that the
@@ -42,6 +82,11 @@ func (e *ErrCannotParseEnv) Cause() error {
 	return e.Because
 }
 
+// Unwrap returns the error that caused the ErrCannotParseEnv, for errors.Is and errors.As
+func (e *ErrCannotParseEnv) Unwrap() error {
+	return e.Because
+}
+
 // ErrCannotSetKind is returned if the kind of a field cannot be set from a string.
 // This indicates that the logic is missing from `setValueFromString` to handle
 // the given reflect.Kind.
@@ -61,6 +106,26 @@ func (e *ErrCannotSetKind) Error() string {
 	return fmt.Sprintf("cannot set kind [%s]", e.Kind.String())
 }
 
+// ErrConflictingOptions is returned if a tag has two options that cannot be used
+// together, e.g. lower and upper
+type ErrConflictingOptions struct {
+	Tag     string
+	Options []string
+}
+
+// NewErrConflictingOptions creates an ErrConflictingOptions
+func NewErrConflictingOptions(tag string, options ...string) *ErrConflictingOptions {
+	return &ErrConflictingOptions{
+		Tag:     tag,
+		Options: options,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrConflictingOptions) Error() string {
+	return fmt.Sprintf("tag [%s] has conflicting options [%s]", e.Tag, strings.Join(e.Options, "|"))
+}
+
 // ErrDecodeFailure is returned by `Retrieve` if the value could not be decoded by the
 // requested decoder
 type ErrDecodeFailure struct {
@@ -97,6 +162,132 @@ func (e *ErrDecodeFailure) Cause() error {
 	return e.Because
 }
 
+// Unwrap returns the error that caused the ErrDecodeFailure, for errors.Is and errors.As
+func (e *ErrDecodeFailure) Unwrap() error {
+	return e.Because
+}
+
+// ErrDefaultFailed is returned if the function registered with RegisterLazyDefault
+// for a missing variable fails
+type ErrDefaultFailed struct {
+	Key     string
+	Because error
+}
+
+// NewErrDefaultFailed creates an ErrDefaultFailed which wraps the error returned by the
+// function
+func NewErrDefaultFailed(key string, err error) *ErrDefaultFailed {
+	return &ErrDefaultFailed{
+		Key:     key,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrDefaultFailed) Error() string {
+	return fmt.Sprintf("cannot compute default for key [%s]: %s", e.Key, e.Because.Error())
+}
+
+// Cause returns the error that caused the ErrDefaultFailed
+func (e *ErrDefaultFailed) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrDefaultFailed, for errors.Is and errors.As
+func (e *ErrDefaultFailed) Unwrap() error {
+	return e.Because
+}
+
+// ErrDefaultsTypeMismatch is returned by GetWithDefaults if the defaults are not of the
+// same struct type as the config.
+type ErrDefaultsTypeMismatch struct {
+	Config   reflect.Type
+	Defaults reflect.Type
+}
+
+// NewErrDefaultsTypeMismatch creates an ErrDefaultsTypeMismatch
+func NewErrDefaultsTypeMismatch(config, defaults reflect.Type) *ErrDefaultsTypeMismatch {
+	return &ErrDefaultsTypeMismatch{
+		Config:   config,
+		Defaults: defaults,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrDefaultsTypeMismatch) Error() string {
+	return fmt.Sprintf("defaults must be of type %s but got %v", e.Config.String(), e.Defaults)
+}
+
+// ErrField wraps an error for a field of a nested struct, so that the error identifies
+// the field by its path from the config, e.g. Database.Primary.Host, as well as by the
+// name of its variable. Errors for fields of the config itself are not wrapped.
+type ErrField struct {
+	Path    string
+	Because error
+}
+
+// NewErrField creates an ErrField which wraps the error
+func NewErrField(path string, err error) *ErrField {
+	return &ErrField{
+		Path:    path,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrField) Error() string {
+	return fmt.Sprintf("field [%s]: %s", e.Path, e.Because.Error())
+}
+
+// Cause returns the error that caused the ErrField
+func (e *ErrField) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrField, for errors.Is and errors.As
+func (e *ErrField) Unwrap() error {
+	return e.Because
+}
+
+// ErrFileRead is returned if the file named by the value of a field tagged with
+// fromfile cannot be read
+type ErrFileRead struct {
+	Key     string
+	Path    string
+	Because error
+}
+
+// NewErrFileRead creates an ErrFileRead which wraps the error describing the cause of
+// the failure
+func NewErrFileRead(key, path string, err error) *ErrFileRead {
+	return &ErrFileRead{
+		Key:     key,
+		Path:    path,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrFileRead) Error() string {
+	result := fmt.Sprintf("cannot read file [%s] for key [%s]", e.Path, e.Key)
+
+	if e.Because != nil {
+		result = fmt.Sprintf("%s: %s", result, e.Because.Error())
+	}
+
+	return result
+}
+
+// Cause returns the error that caused the ErrFileRead
+func (e *ErrFileRead) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrFileRead, for errors.Is and errors.As
+func (e *ErrFileRead) Unwrap() error {
+	return e.Because
+}
+
 // ErrInvalidConfigType is returned if Get is called with a value that is not a pointer
 // to a struct. It must be a pointer so that Get can modify the values. It must be a
 // struct to have tagged fields.
@@ -113,7 +304,43 @@ func NewErrInvalidConfigType(t reflect.Type) *ErrInvalidConfigType {
 
 // Error returns a human-readable description of the error
 func (e *ErrInvalidConfigType) Error() string {
-	return fmt.Sprintf("config must be pointer to struct but got %s", e.Type.String())
+	return fmt.Sprintf("config must be pointer to struct but got %v", e.Type)
+}
+
+// ErrInvalidPattern is returned if the pattern given in a tag is not a valid regular
+// expression
+type ErrInvalidPattern struct {
+	Pattern string
+	Because error
+}
+
+// NewErrInvalidPattern creates an ErrInvalidPattern which wraps the compilation error
+func NewErrInvalidPattern(pattern string, err error) *ErrInvalidPattern {
+	return &ErrInvalidPattern{
+		Pattern: pattern,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrInvalidPattern) Error() string {
+	result := fmt.Sprintf("invalid pattern [%s]", e.Pattern)
+
+	if e.Because != nil {
+		result = fmt.Sprintf("%s: %s", result, e.Because.Error())
+	}
+
+	return result
+}
+
+// Cause returns the error that caused the ErrInvalidPattern
+func (e *ErrInvalidPattern) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrInvalidPattern, for errors.Is and errors.As
+func (e *ErrInvalidPattern) Unwrap() error {
+	return e.Because
 }
 
 // ErrInvalidTagOption is returned if the struct field tag has an unsupported option.
@@ -135,6 +362,68 @@ func (e *ErrInvalidTagOption) Error() string {
 	return fmt.Sprintf("tag [%s] contains unsupported option [%s]", e.Tag, e.BadOption)
 }
 
+// ErrLengthViolation is returned if the length of a string or []byte field is less
+// than the minlen or greater than the maxlen given in the tag. The length of a string
+// is measured in runes, while the length of a []byte is measured in bytes.
+type ErrLengthViolation struct {
+	Key string
+	Len int
+	Min string
+	Max string
+}
+
+// NewErrLengthViolation creates an ErrLengthViolation
+func NewErrLengthViolation(key string, length int, min, max string) *ErrLengthViolation {
+	return &ErrLengthViolation{
+		Key: key,
+		Len: length,
+		Min: min,
+		Max: max,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrLengthViolation) Error() string {
+	return fmt.Sprintf("length [%d] for key [%s] is out of range [%s, %s]", e.Len, e.Key, e.Min, e.Max)
+}
+
+// ErrLookupFailed is returned if the lookup of a variable fails, e.g. because
+// LookupCtxFn returned an error or the context given to GetContext is done
+type ErrLookupFailed struct {
+	Key     string
+	Because error
+}
+
+// NewErrLookupFailed creates an ErrLookupFailed which wraps the error describing the
+// cause of the failure
+func NewErrLookupFailed(key string, err error) *ErrLookupFailed {
+	return &ErrLookupFailed{
+		Key:     key,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrLookupFailed) Error() string {
+	result := fmt.Sprintf("failed to look up var for key [%s]", e.Key)
+
+	if e.Because != nil {
+		result = fmt.Sprintf("%s: %s", result, e.Because.Error())
+	}
+
+	return result
+}
+
+// Cause returns the error that caused the ErrLookupFailed
+func (e *ErrLookupFailed) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrLookupFailed, for errors.Is and errors.As
+func (e *ErrLookupFailed) Unwrap() error {
+	return e.Because
+}
+
 // ErrMissingNameTag is returned if the passed config struct field is tagged but no
 // name is provided, e.g. `env:""`
 type ErrMissingNameTag struct {
@@ -153,6 +442,130 @@ func (e *ErrMissingNameTag) Error() string {
 	return fmt.Sprintf("tagged field must be named but got [%s]", e.Tag)
 }
 
+// ErrMultiple is returned if the Parser collects errors and one or more fields fail.
+// The errors are in the order of the fields: declaration order, depth-first through
+// nested structs and the elements of indexed slices.
+type ErrMultiple struct {
+	Errors []error
+}
+
+// NewErrMultiple creates an ErrMultiple error
+func NewErrMultiple(errs []error) *ErrMultiple {
+	return &ErrMultiple{
+		Errors: errs,
+	}
+}
+
+// Error returns a human-readable description of the error, listing every error
+func (e *ErrMultiple) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d errors: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As
+func (e *ErrMultiple) Unwrap() []error {
+	return e.Errors
+}
+
+// ErrNamespace wraps any error returned by a Parser that has a Namespace, so that the
+// error identifies the component being configured
+type ErrNamespace struct {
+	Namespace string
+	Because   error
+}
+
+// NewErrNamespace creates an ErrNamespace which wraps the error
+func NewErrNamespace(namespace string, err error) *ErrNamespace {
+	return &ErrNamespace{
+		Namespace: namespace,
+		Because:   err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrNamespace) Error() string {
+	return fmt.Sprintf("%s: %s", e.Namespace, e.Because.Error())
+}
+
+// Cause returns the error that caused the ErrNamespace
+func (e *ErrNamespace) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrNamespace, for errors.Is and errors.As
+func (e *ErrNamespace) Unwrap() error {
+	return e.Because
+}
+
+// ErrNotAddressable is returned by ParseValue if the struct cannot be set, e.g. because
+// it was passed by value rather than through a pointer
+type ErrNotAddressable struct {
+	Type reflect.Type
+}
+
+// NewErrNotAddressable creates an ErrNotAddressable
+func NewErrNotAddressable(t reflect.Type) *ErrNotAddressable {
+	return &ErrNotAddressable{
+		Type: t,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrNotAddressable) Error() string {
+	return fmt.Sprintf("config of type %s must be addressable, e.g. obtained from a pointer", e.Type.String())
+}
+
+// ErrNotInEnum is returned if the value of a field tagged with oneof is not one of
+// the allowed values
+type ErrNotInEnum struct {
+	Key     string
+	Value   string
+	Allowed []string
+}
+
+// NewErrNotInEnum creates an ErrNotInEnum
+func NewErrNotInEnum(key, value string, allowed []string) *ErrNotInEnum {
+	return &ErrNotInEnum{
+		Key:     key,
+		Value:   value,
+		Allowed: allowed,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrNotInEnum) Error() string {
+	return fmt.Sprintf("value [%s] for key [%s] is not one of [%s]", e.Value, e.Key, strings.Join(e.Allowed, "|"))
+}
+
+// ErrOutOfRange is returned if the value of a numeric field is less than the min or
+// greater than the max given in the tag. The bounds are inclusive, and an empty bound
+// is not checked.
+type ErrOutOfRange struct {
+	Key   string
+	Value string
+	Min   string
+	Max   string
+}
+
+// NewErrOutOfRange creates an ErrOutOfRange
+func NewErrOutOfRange(key, value, min, max string) *ErrOutOfRange {
+	return &ErrOutOfRange{
+		Key:   key,
+		Value: value,
+		Min:   min,
+		Max:   max,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrOutOfRange) Error() string {
+	return fmt.Sprintf("value [%s] for key [%s] is out of range [%s, %s]", e.Value, e.Key, e.Min, e.Max)
+}
+
 // ErrOverflow is returned if a numeric reflect.Value cannot be set because it would result in an overflow
 type ErrOverflow struct {
 	Kind  reflect.Kind
@@ -174,6 +587,80 @@ func (e *ErrOverflow) Error() string {
 	return fmt.Sprintf("overflow detected trying to set field of kind [%s] to value [%s] for key [%s]", e.Kind.String(), e.Value, e.Key)
 }
 
+// ErrPatternMismatch is returned if the value of a field does not match the pattern
+// given in the tag
+type ErrPatternMismatch struct {
+	Key     string
+	Value   string
+	Pattern string
+}
+
+// NewErrPatternMismatch creates an ErrPatternMismatch
+func NewErrPatternMismatch(key, value, pattern string) *ErrPatternMismatch {
+	return &ErrPatternMismatch{
+		Key:     key,
+		Value:   value,
+		Pattern: pattern,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrPatternMismatch) Error() string {
+	return fmt.Sprintf("value [%s] for key [%s] does not match pattern [%s]", e.Value, e.Key, e.Pattern)
+}
+
+// ErrUntaggedField is returned by GetStrict for an exported field that has no tag,
+// given by its path from the config, e.g. Database.Host
+type ErrUntaggedField struct {
+	Name string
+}
+
+// NewErrUntaggedField creates an ErrUntaggedField
+func NewErrUntaggedField(name string) *ErrUntaggedField {
+	return &ErrUntaggedField{
+		Name: name,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrUntaggedField) Error() string {
+	return fmt.Sprintf("field [%s] is exported but has no tag", e.Name)
+}
+
+// ErrValidation is returned if the Validate method of a decoded value, or of one of its
+// elements, fails. The key identifies an element by its index or key, e.g. SERVERS[1].
+type ErrValidation struct {
+	Key     string
+	Because error
+}
+
+// NewErrValidation creates an ErrValidation which wraps the error returned by Validate
+func NewErrValidation(key string, err error) *ErrValidation {
+	return &ErrValidation{
+		Key:     key,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrValidation) Error() string {
+	return fmt.Sprintf("validation failed for key [%s]: %v", e.Key, e.Because)
+}
+
+// Cause returns the error that caused the ErrValidation
+func (e *ErrValidation) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrValidation, for errors.Is and errors.As
+func (e *ErrValidation) Unwrap() error {
+	return e.Because
+}
+
+// ErrNotFound matches any ErrVarNotFound with errors.Is, e.g.
+// errors.Is(err, libconfig.ErrNotFound)
+var ErrNotFound = errors.New("var not found")
+
 // ErrVarNotFound is returned if the given key is not found by the lookup function
 type ErrVarNotFound struct {
 	Key string
@@ -191,6 +678,65 @@ func (e *ErrVarNotFound) Error() string {
 	return fmt.Sprintf("var not found for key [%s]", e.Key)
 }
 
+// Is returns true if the target is ErrNotFound
+func (e *ErrVarNotFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ErrWithMessage is returned if a field whose tag has the msg option fails, adding the
+// message, e.g. guidance on where the value should come from, to the error
+type ErrWithMessage struct {
+	Key     string
+	Message string
+	Because error
+}
+
+// NewErrWithMessage creates an ErrWithMessage which wraps the error for the field
+func NewErrWithMessage(key, message string, err error) *ErrWithMessage {
+	return &ErrWithMessage{
+		Key:     key,
+		Message: message,
+		Because: err,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrWithMessage) Error() string {
+	return fmt.Sprintf("%v: %s", e.Because, e.Message)
+}
+
+// Cause returns the error that caused the ErrWithMessage
+func (e *ErrWithMessage) Cause() error {
+	return e.Because
+}
+
+// Unwrap returns the error that caused the ErrWithMessage, for errors.Is and errors.As
+func (e *ErrWithMessage) Unwrap() error {
+	return e.Because
+}
+
+// ErrWrongLength is returned if the bytes for a fixed-length field, e.g. a [16]byte
+// decoded from hex, are not exactly the length of the field
+type ErrWrongLength struct {
+	Key      string
+	Expected int
+	Actual   int
+}
+
+// NewErrWrongLength creates an ErrWrongLength
+func NewErrWrongLength(key string, expected, actual int) *ErrWrongLength {
+	return &ErrWrongLength{
+		Key:      key,
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+// Error returns a human-readable description of the error
+func (e *ErrWrongLength) Error() string {
+	return fmt.Sprintf("value for key [%s] has %d bytes but must have exactly %d", e.Key, e.Actual, e.Expected)
+}
+
 // ErrNestedTags is returned if a tagged struct contains a tagged field, which, if supported, could
 // result in unexpected behavior due to the parsing order of structs and struct fields
 type ErrNestedTags struct {
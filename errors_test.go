@@ -1,8 +1,10 @@
 package libconfig_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -11,6 +13,11 @@ import (
 	"github.com/jrudder/libconfig"
 )
 
+func TestErrAlreadySet(t *testing.T) {
+	err := libconfig.NewErrAlreadySet("key")
+	require.Equal(t, "field for key [key] is already set", err.Error(), "error string must match")
+}
+
 func TestErrCannotParseEnv(t *testing.T) {
 	cause := fmt.Errorf("some error")
 	err := libconfig.NewErrCannotParseEnv(cause, reflect.Int, "key", "value")
@@ -29,11 +36,21 @@ func TestErrCannotParseEnvCause(t *testing.T) {
 	require.Equal(t, expected, cause, "ErrCannotParseEnv must have a cause")
 }
 
+func TestErrCannotDump(t *testing.T) {
+	err := libconfig.NewErrCannotDump("key", reflect.TypeOf([]byte{}))
+	require.Equal(t, "cannot dump value of type [[]uint8] for key [key]", err.Error(), "error string must match")
+}
+
 func TestErrCannotSetKind(t *testing.T) {
 	err := libconfig.NewErrCannotSetKind(reflect.Interface)
 	require.Equal(t, "cannot set kind [interface]", err.Error(), "error string must match")
 }
 
+func TestErrConflictingOptions(t *testing.T) {
+	err := libconfig.NewErrConflictingOptions("KEY,lower,upper", "lower", "upper")
+	require.Equal(t, "tag [KEY,lower,upper] has conflicting options [lower|upper]", err.Error(), "error string must match")
+}
+
 func TestErrDecodeFailure(t *testing.T) {
 	cause := fmt.Errorf("some error")
 	err := libconfig.NewErrDecodeFailure(cause, "key", "value", "base64")
@@ -52,6 +69,38 @@ func TestErrDecodeFailureCause(t *testing.T) {
 	require.Equal(t, expected, cause, "ErrDecodeFailure must have a cause")
 }
 
+func TestErrDefaultFailed(t *testing.T) {
+	err := libconfig.NewErrDefaultFailed("key", errors.New("some error"))
+	require.Equal(t, "cannot compute default for key [key]: some error", err.Error(), "error string must match")
+}
+
+func TestErrDefaultsTypeMismatch(t *testing.T) {
+	err := libconfig.NewErrDefaultsTypeMismatch(reflect.TypeOf(struct{}{}), reflect.TypeOf(int(623)))
+	require.Equal(t, "defaults must be of type struct {} but got int", err.Error(), "error string must match")
+}
+
+func TestErrNamespace(t *testing.T) {
+	err := libconfig.NewErrNamespace("billing", fmt.Errorf("some error"))
+	require.Equal(t, "billing: some error", err.Error(), "error string must match")
+}
+
+func TestErrNamespaceCause(t *testing.T) {
+	expected := errors.New("some error")
+	err := libconfig.NewErrNamespace("billing", expected)
+	cause := errors.Cause(err)
+	require.Equal(t, expected, cause, "ErrNamespace must have a cause")
+}
+
+func TestErrField(t *testing.T) {
+	err := libconfig.NewErrField("Database.Host", libconfig.NewErrVarNotFound("DB_HOST"))
+	require.Equal(t, "field [Database.Host]: var not found for key [DB_HOST]", err.Error(), "error string must match")
+}
+
+func TestErrFileRead(t *testing.T) {
+	err := libconfig.NewErrFileRead("key", "/run/secrets/key", errors.New("some error"))
+	require.Equal(t, "cannot read file [/run/secrets/key] for key [key]: some error", err.Error(), "error string must match")
+}
+
 func TestErrInvalidConfigType(t *testing.T) {
 	err := libconfig.NewErrInvalidConfigType(reflect.TypeOf(int(623)))
 	require.Equal(t, "config must be pointer to struct but got int", err.Error(), "error string must match")
@@ -63,27 +112,275 @@ func TestErrInvalidConfigTypePtr(t *testing.T) {
 	require.Equal(t, "config must be pointer to struct but got *int", err.Error(), "error string must match")
 }
 
+func TestErrInvalidPattern(t *testing.T) {
+	err := libconfig.NewErrInvalidPattern("(", fmt.Errorf("some error"))
+	require.Equal(t, "invalid pattern [(]: some error", err.Error(), "error string must match")
+}
+
+func TestErrInvalidPatternWithoutCause(t *testing.T) {
+	err := libconfig.NewErrInvalidPattern("(", nil)
+	require.Equal(t, "invalid pattern [(]", err.Error(), "error string must match")
+}
+
+func TestErrInvalidPatternCause(t *testing.T) {
+	expected := errors.New("some error")
+	err := libconfig.NewErrInvalidPattern("(", expected)
+	cause := errors.Cause(err)
+	require.Equal(t, expected, cause, "ErrInvalidPattern must have a cause")
+}
+
 func TestErrInvalidTagOption(t *testing.T) {
 	err := libconfig.NewErrInvalidTagOption("tag,here", "something")
 	require.Equal(t, "tag [tag,here] contains unsupported option [something]", err.Error(), "error string must match")
 }
 
+func TestErrLengthViolation(t *testing.T) {
+	err := libconfig.NewErrLengthViolation("key", 3, "4", "")
+	require.Equal(t, "length [3] for key [key] is out of range [4, ]", err.Error(), "error string must match")
+}
+
 func TestErrMissingNameTag(t *testing.T) {
 	err := libconfig.NewErrMissingNameTag("some-tag")
 	require.Equal(t, "tagged field must be named but got [some-tag]", err.Error(), "error string must match")
 }
 
+func TestErrNotInEnum(t *testing.T) {
+	err := libconfig.NewErrNotInEnum("key", "value", []string{"a", "b"})
+	require.Equal(t, "value [value] for key [key] is not one of [a|b]", err.Error(), "error string must match")
+}
+
+func TestErrOutOfRange(t *testing.T) {
+	err := libconfig.NewErrOutOfRange("key", "0", "1", "10")
+	require.Equal(t, "value [0] for key [key] is out of range [1, 10]", err.Error(), "error string must match")
+}
+
 func TestErrOverflow(t *testing.T) {
 	err := libconfig.NewErrOverflow(reflect.Int8, "key", "value")
 	require.Equal(t, "overflow detected trying to set field of kind [int8] to value [value] for key [key]", err.Error(), "error string must match")
 }
 
+func TestErrPatternMismatch(t *testing.T) {
+	err := libconfig.NewErrPatternMismatch("key", "value", "^a$")
+	require.Equal(t, "value [value] for key [key] does not match pattern [^a$]", err.Error(), "error string must match")
+}
+
+func TestErrUntaggedField(t *testing.T) {
+	err := libconfig.NewErrUntaggedField("Field")
+	require.Equal(t, "field [Field] is exported but has no tag", err.Error(), "error string must match")
+}
+
+func TestErrValidation(t *testing.T) {
+	err := libconfig.NewErrValidation("key[1]", errors.New("host is required"))
+	require.Equal(t, "validation failed for key [key[1]]: host is required", err.Error(), "error string must match")
+}
+
 func TestErrVarNotFound(t *testing.T) {
 	err := libconfig.NewErrVarNotFound("key")
 	require.Equal(t, "var not found for key [key]", err.Error(), "error string must match")
 }
 
+func TestErrWithMessage(t *testing.T) {
+	err := libconfig.NewErrWithMessage("key", "set via vault", libconfig.NewErrVarNotFound("key"))
+	require.Equal(t, "var not found for key [key]: set via vault", err.Error(), "error string must match")
+}
+
+func TestErrWrongLength(t *testing.T) {
+	err := libconfig.NewErrWrongLength("key", 16, 15)
+	require.Equal(t, "value for key [key] has 15 bytes but must have exactly 16", err.Error(), "error string must match")
+}
+
 func TestErrNestedTags(t *testing.T) {
 	err := libconfig.NewErrNestedTags("field", "key")
 	require.Equal(t, "field [field] with key [key] contains one or more nested subfields", err.Error(), "error string must match")
 }
+
+func TestErrVarNotFoundJSON(t *testing.T) {
+	err := libconfig.NewErrVarNotFound("key")
+	data, jsonErr := json.Marshal(err)
+
+	require := require.New(t)
+	require.NoError(jsonErr, "Marshal should not fail")
+	require.JSONEq(`{
+		"code": "var_not_found",
+		"message": "var not found for key [key]",
+		"key": "key"
+	}`, string(data), "JSON must match")
+	require.Equal("var_not_found", err.ErrorCode(), "code must match")
+}
+
+func TestErrCannotParseEnvJSON(t *testing.T) {
+	err := libconfig.NewErrCannotParseEnv(fmt.Errorf("some error"), reflect.Int, "key", "value")
+	data, jsonErr := json.Marshal(err)
+
+	require := require.New(t)
+	require.NoError(jsonErr, "Marshal should not fail")
+	require.JSONEq(`{
+		"code": "cannot_parse_env",
+		"message": "cannot parse env [key] with value [value] to kind [int]: some error",
+		"kind": "int",
+		"key": "key",
+		"value": "value",
+		"because": "some error"
+	}`, string(data), "JSON must include the cause")
+}
+
+func TestErrCannotParseEnvWithoutCauseJSON(t *testing.T) {
+	err := libconfig.NewErrCannotParseEnv(nil, reflect.Int, "key", "value")
+	data, jsonErr := json.Marshal(err)
+
+	require := require.New(t)
+	require.NoError(jsonErr, "Marshal should not fail")
+	require.JSONEq(`{
+		"code": "cannot_parse_env",
+		"message": "cannot parse env [key] with value [value] to kind [int]",
+		"kind": "int",
+		"key": "key",
+		"value": "value"
+	}`, string(data), "JSON must omit the missing cause")
+}
+
+func TestErrNamespaceJSON(t *testing.T) {
+	err := libconfig.NewErrNamespace("db", libconfig.NewErrNotInEnum("key", "c", []string{"a", "b"}))
+	data, jsonErr := json.Marshal(err)
+
+	require := require.New(t)
+	require.NoError(jsonErr, "Marshal should not fail")
+	require.JSONEq(`{
+		"code": "namespace",
+		"message": "db: value [c] for key [key] is not one of [a|b]",
+		"namespace": "db",
+		"because": {
+			"code": "not_in_enum",
+			"message": "value [c] for key [key] is not one of [a|b]",
+			"key": "key",
+			"value": "c",
+			"allowed": ["a", "b"]
+		}
+	}`, string(data), "JSON must nest the cause")
+}
+
+func TestErrorCodes(t *testing.T) {
+	errs := map[string]interface{ ErrorCode() string }{
+		"already_set":            libconfig.NewErrAlreadySet("key"),
+		"cannot_dump":            libconfig.NewErrCannotDump("key", reflect.TypeOf(0)),
+		"cannot_parse_env":       libconfig.NewErrCannotParseEnv(nil, reflect.Int, "key", "value"),
+		"cannot_set_kind":        libconfig.NewErrCannotSetKind(reflect.Interface),
+		"conflicting_options":    libconfig.NewErrConflictingOptions("KEY,lower,upper", "lower", "upper"),
+		"decode_failure":         libconfig.NewErrDecodeFailure(nil, "key", "value", "json"),
+		"default_failed":         libconfig.NewErrDefaultFailed("key", errors.New("some error")),
+		"defaults_type_mismatch": libconfig.NewErrDefaultsTypeMismatch(reflect.TypeOf(struct{}{}), nil),
+		"field":                  libconfig.NewErrField("Field", errors.New("some error")),
+		"file_read":              libconfig.NewErrFileRead("key", "path", nil),
+		"invalid_config_type":    libconfig.NewErrInvalidConfigType(reflect.TypeOf(0)),
+		"invalid_pattern":        libconfig.NewErrInvalidPattern("(", nil),
+		"invalid_tag_option":     libconfig.NewErrInvalidTagOption("KEY,bad", "bad"),
+		"length_violation":       libconfig.NewErrLengthViolation("key", 1, "2", ""),
+		"lookup_failed":          libconfig.NewErrLookupFailed("key", nil),
+		"missing_name_tag":       libconfig.NewErrMissingNameTag(""),
+		"multiple":               libconfig.NewErrMultiple([]error{errors.New("some error")}),
+		"namespace":              libconfig.NewErrNamespace("db", errors.New("some error")),
+		"not_addressable":        libconfig.NewErrNotAddressable(reflect.TypeOf(0)),
+		"not_in_enum":            libconfig.NewErrNotInEnum("key", "c", []string{"a"}),
+		"out_of_range":           libconfig.NewErrOutOfRange("key", "0", "1", ""),
+		"overflow":               libconfig.NewErrOverflow(reflect.Int8, "key", "500"),
+		"pattern_mismatch":       libconfig.NewErrPatternMismatch("key", "value", "^a$"),
+		"untagged_field":         libconfig.NewErrUntaggedField("Field"),
+		"validation":             libconfig.NewErrValidation("key", errors.New("some error")),
+		"var_not_found":          libconfig.NewErrVarNotFound("key"),
+		"with_message":           libconfig.NewErrWithMessage("key", "message", errors.New("some error")),
+		"wrong_length":           libconfig.NewErrWrongLength("key", 16, 15),
+		"nested_tags":            libconfig.NewErrNestedTags("Field", "key"),
+	}
+
+	require := require.New(t)
+	for code, err := range errs {
+		require.Equal(code, err.ErrorCode(), "code must match")
+		_, jsonErr := json.Marshal(err)
+		require.NoError(jsonErr, "Marshal should not fail for %s", code)
+	}
+}
+
+func TestErrCannotParseEnvUnwrap(t *testing.T) {
+	_, cause := strconv.Atoi("value")
+	err := libconfig.NewErrCannotParseEnv(cause, reflect.Int, "key", "value")
+
+	require := require.New(t)
+	require.True(errors.Is(err, strconv.ErrSyntax), "errors.Is must find the wrapped error")
+
+	var numErr *strconv.NumError
+	require.True(errors.As(err, &numErr), "errors.As must find the wrapped error")
+	require.Equal("value", numErr.Num, "errors.As must set the wrapped error")
+}
+
+func TestErrDecodeFailureUnwrap(t *testing.T) {
+	expected := errors.New("some error")
+	err := libconfig.NewErrDecodeFailure(expected, "key", "value", "base64")
+	require.True(t, errors.Is(err, expected), "errors.Is must find the wrapped error")
+}
+
+func TestErrFieldUnwrap(t *testing.T) {
+	err := libconfig.NewErrField("Database.Host", libconfig.NewErrVarNotFound("DB_HOST"))
+
+	require := require.New(t)
+	var notFound *libconfig.ErrVarNotFound
+	require.True(errors.As(err, &notFound), "errors.As must find the wrapped error")
+	require.Equal("DB_HOST", notFound.Key, "errors.As must set the wrapped error")
+	require.True(errors.Is(err, libconfig.ErrNotFound), "errors.Is must see through ErrField")
+}
+
+func TestErrNotFound(t *testing.T) {
+	err := libconfig.NewErrNamespace("db", libconfig.NewErrVarNotFound("key"))
+
+	require := require.New(t)
+	require.True(errors.Is(err, libconfig.ErrNotFound), "errors.Is must match ErrNotFound")
+	require.False(errors.Is(libconfig.NewErrVarNotFound("key"), libconfig.ErrEnumUnavailable), "errors.Is must not match other errors")
+
+	var notFound *libconfig.ErrVarNotFound
+	require.True(errors.As(err, &notFound), "errors.As must find the ErrVarNotFound")
+	require.Equal("key", notFound.Key, "errors.As must set the ErrVarNotFound")
+}
+
+func TestErrLookupFailed(t *testing.T) {
+	cause := fmt.Errorf("some error")
+	err := libconfig.NewErrLookupFailed("key", cause)
+	require.Equal(t, "failed to look up var for key [key]: some error", err.Error(), "error string must match")
+}
+
+func TestErrLookupFailedCause(t *testing.T) {
+	expected := errors.New("some error")
+	err := libconfig.NewErrLookupFailed("key", expected)
+	require.Equal(t, expected, errors.Cause(err), "ErrLookupFailed must have a cause")
+	require.True(t, errors.Is(err, expected), "errors.Is must find the wrapped error")
+}
+
+func TestErrNotAddressable(t *testing.T) {
+	err := libconfig.NewErrNotAddressable(reflect.TypeOf(struct{}{}))
+	require.Equal(t, "config of type struct {} must be addressable, e.g. obtained from a pointer", err.Error(), "error string must match")
+}
+
+func TestErrMultiple(t *testing.T) {
+	err := libconfig.NewErrMultiple([]error{
+		libconfig.NewErrVarNotFound("VAR_A"),
+		libconfig.NewErrVarNotFound("VAR_B"),
+	})
+	require.Equal(t, "2 errors: var not found for key [VAR_A]; var not found for key [VAR_B]", err.Error(), "error string must match")
+}
+
+func TestErrMultipleJSON(t *testing.T) {
+	err := libconfig.NewErrMultiple([]error{
+		libconfig.NewErrVarNotFound("VAR_A"),
+		errors.New("some error"),
+	})
+	data, jsonErr := json.Marshal(err)
+
+	require := require.New(t)
+	require.NoError(jsonErr, "Marshal should not fail")
+	require.JSONEq(`{
+		"code": "multiple",
+		"message": "2 errors: var not found for key [VAR_A]; some error",
+		"errors": [
+			{"code": "var_not_found", "message": "var not found for key [VAR_A]", "key": "VAR_A"},
+			"some error"
+		]
+	}`, string(data), "JSON must list every error")
+}
@@ -0,0 +1,79 @@
+package libconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// refPattern matches a value that is entirely a reference to another variable
+var refPattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// expand replaces ${VAR} and $VAR references in the value with the values of those
+// variables, as found by the Parser's LookupFn. References in the referenced values
+// are expanded too. A reference to a missing variable expands to "" unless the tag
+// is expand=strict, in which case ErrVarNotFound is returned for that variable.
+func (p *Parser) expand(ctx context.Context, tag tagData, value string) (string, error) {
+	var err error
+
+	// expanding tracks the variables being expanded in order to detect cycles
+	expanding := map[string]bool{tag.Name: true}
+
+	var mapping func(name string) string
+	mapping = func(name string) string {
+		if err != nil {
+			return ""
+		}
+
+		if expanding[name] {
+			cause := fmt.Errorf("reference cycle through [%s]", name)
+			err = NewErrDecodeFailure(cause, tag.Name, value, "expand")
+			return ""
+		}
+
+		ref, found, lookupErr := p.lookupVar(ctx, name)
+		if lookupErr != nil {
+			err = lookupErr
+			return ""
+		}
+		if !found {
+			if tag.ExpandStrict {
+				err = NewErrVarNotFound(name)
+			}
+			return ""
+		}
+
+		expanding[name] = true
+		ref = os.Expand(ref, mapping)
+		delete(expanding, name)
+
+		return ref
+	}
+
+	result := os.Expand(value, mapping)
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// resolveRef replaces a value of the form $OTHER_VAR with the value of OTHER_VAR, as
+// found by the Parser's LookupFn, returning the name of the reference and whether it
+// was found. The referenced value is not resolved again, and any other value is
+// returned as is.
+func (p *Parser) resolveRef(ctx context.Context, value string) (string, string, bool, error) {
+	match := refPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, "", true, nil
+	}
+
+	ref := match[1]
+	resolved, found, err := p.lookupVar(ctx, ref)
+	if err != nil {
+		return "", ref, false, err
+	}
+
+	return resolved, ref, found, nil
+}
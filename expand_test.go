@@ -0,0 +1,221 @@
+package libconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestExpand(t *testing.T) {
+	type Config struct {
+		URL  string `env:"URL,expand"`
+		Port int    `env:"PORT,expand"`
+	}
+
+	p := mapToParser(map[string]string{
+		"URL":          "https://${HOST}:$DEFAULT_PORT/",
+		"HOST":         "example.com",
+		"PORT":         "${DEFAULT_PORT}",
+		"DEFAULT_PORT": "8443",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("https://example.com:8443/", config.URL, "URL should be expanded")
+	require.Equal(8443, config.Port, "Port should be expanded before parsing")
+}
+
+func TestExpandNested(t *testing.T) {
+	type Config struct {
+		URL string `env:"URL,expand"`
+	}
+
+	p := mapToParser(map[string]string{
+		"URL":  "https://${ADDR}/",
+		"ADDR": "${HOST}:${PORT}",
+		"HOST": "example.com",
+		"PORT": "8443",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("https://example.com:8443/", config.URL, "references in ADDR should be expanded too")
+}
+
+func TestExpandMissing(t *testing.T) {
+	type Config struct {
+		URL string `env:"URL,expand"`
+	}
+
+	p := mapToParser(map[string]string{
+		"URL":  "https://${HOST}:${PORT}/",
+		"HOST": "example.com",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("https://example.com:/", config.URL, "PORT should expand to an empty string")
+}
+
+func TestExpandStrictMissing(t *testing.T) {
+	type Config struct {
+		URL string `env:"URL,expand=strict"`
+	}
+
+	p := mapToParser(map[string]string{
+		"URL":  "https://${HOST}:${PORT}/",
+		"HOST": "example.com",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("PORT")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because PORT is missing")
+}
+
+func TestExpandCycle(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,expand"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "a-${VAR_B}",
+		"VAR_B": "b-${VAR_A}",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because of the cycle")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("expand", specificErr.Type, "the error should be for expand")
+	require.Equal("VAR_A", specificErr.Key, "the error should be for VAR_A")
+}
+
+func TestExpandInvalidOption(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,expand=lenient"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,expand=lenient", "expand=lenient")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because of the invalid option")
+}
+
+func TestResolveRefs(t *testing.T) {
+	type Config struct {
+		DatabaseURL string `env:"DATABASE_URL"`
+		Price       string `env:"PRICE"`
+		Braced      string `env:"BRACED"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DATABASE_URL": "$DB_URL",
+		"DB_URL":       "postgres://localhost/app",
+		"PRICE":        "$5",
+		"BRACED":       "${DB_URL}",
+	})
+	p.ResolveRefs = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("postgres://localhost/app", config.DatabaseURL, "the reference should be resolved")
+	require.Equal("$5", config.Price, "values that are not names should be kept")
+	require.Equal("${DB_URL}", config.Braced, "only exact references without braces should be resolved")
+}
+
+func TestResolveRefsChained(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "$VAR_B",
+		"VAR_B": "$VAR_C",
+		"VAR_C": "VAL_C",
+	})
+	p.ResolveRefs = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("$VAR_C", config.VarA, "references should only be resolved one level deep")
+}
+
+func TestResolveRefsDisabled(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "$VAR_B",
+		"VAR_B": "VAL_B",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("$VAR_B", config.VarA, "references should not be resolved by default")
+}
+
+func TestResolveRefsNotFound(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "$VAR_B",
+	})
+	p.ResolveRefs = true
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrVarNotFound("VAR_B")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail for the missing reference")
+}
+
+func TestResolveRefsOptionalNotFound(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional,default=VAL_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "$VAR_B",
+	})
+	p.ResolveRefs = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "an optional field with a missing reference should be treated as not found")
+}
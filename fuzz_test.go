@@ -0,0 +1,190 @@
+package libconfig_test
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fuzzSetValueConfigs returns a config per kind, each populated from the variable V
+func fuzzSetValueConfigs() []interface{} {
+	return []interface{}{
+		&struct {
+			V string `env:"V"`
+		}{},
+		&struct {
+			V bool `env:"V"`
+		}{},
+		&struct {
+			V int `env:"V"`
+		}{},
+		&struct {
+			V int8 `env:"V"`
+		}{},
+		&struct {
+			V int64 `env:"V"`
+		}{},
+		&struct {
+			V uint `env:"V"`
+		}{},
+		&struct {
+			V uint8 `env:"V"`
+		}{},
+		&struct {
+			V uint64 `env:"V"`
+		}{},
+		&struct {
+			V float32 `env:"V"`
+		}{},
+		&struct {
+			V float64 `env:"V"`
+		}{},
+		&struct {
+			V []byte `env:"V"`
+		}{},
+		&struct {
+			V [4]byte `env:"V"`
+		}{},
+		&struct {
+			V *int `env:"V"`
+		}{},
+		&struct {
+			V **string `env:"V"`
+		}{},
+		&struct {
+			V time.Duration `env:"V"`
+		}{},
+		&struct {
+			V map[string]int `env:"V"`
+		}{},
+		&struct {
+			V map[int]time.Duration `env:"V"`
+		}{},
+		&struct {
+			V *regexp.Regexp `env:"V"`
+		}{},
+		&struct {
+			V atomic.Int32 `env:"V"`
+		}{},
+		&struct {
+			V net.IP `env:"V"`
+		}{},
+		&struct {
+			V json.RawMessage `env:"V,json"`
+		}{},
+	}
+}
+
+func FuzzSetValue(f *testing.F) {
+	// At least one seed per kind
+	for _, seed := range []string{
+		"", "value", "true", "0", "-1", "127", "-129", "255", "18446744073709551615",
+		"1.5", "1e400", "NaN", "abcd", "1h30m", "a=1,b=2", "1=1s", "^a+$", "[", "127.0.0.1",
+		`{"a":1}`, "\xff\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		p := mapToParser(map[string]string{"V": value})
+		p.CoerceFloatToInt = true
+		p.ExtendedBools = true
+
+		// Only errors are acceptable, never panics
+		for _, config := range fuzzSetValueConfigs() {
+			_ = p.Get(config)
+		}
+	})
+}
+
+// fuzzRetrieveConfigs returns a config per combination of tag options, each populated
+// from the variable V
+func fuzzRetrieveConfigs() []interface{} {
+	type pair struct {
+		A int
+		B string
+	}
+
+	return []interface{}{
+		&struct {
+			V []byte `env:"V,base64"`
+		}{},
+		&struct {
+			V string `env:"V,base64,gzip"`
+		}{},
+		&struct {
+			V [][]byte `env:"V,base64"`
+		}{},
+		&struct {
+			V [8]byte `env:"V,hex"`
+		}{},
+		&struct {
+			V pair `env:"V,json"`
+		}{},
+		&struct {
+			V []int `env:"V,json"`
+		}{},
+		&struct {
+			V pair `env:"V,poscsv,fillmissing"`
+		}{},
+		&struct {
+			V []*uint16 `env:"V,csv"`
+		}{},
+		&struct {
+			V rune `env:"V,char"`
+		}{},
+		&struct {
+			V int32 `env:"V,base=0"`
+		}{},
+		&struct {
+			V time.Duration `env:"V,unit=ms"`
+		}{},
+		&struct {
+			V uint32 `env:"V,unit"`
+		}{},
+		&struct {
+			V string `env:"V,oneof=debug|info,fuzzy,lower"`
+		}{},
+		&struct {
+			V string `env:"V,expand"`
+		}{},
+		&struct {
+			V float64 `env:"V,min=-1.5,max=1e3"`
+		}{},
+		&struct {
+			V string `env:"V,minlen=2,maxlen=4,pattern=^[a-z]+$"`
+		}{},
+		&struct {
+			V []string `env:"V,csv,minlen=1,maxlen=3"`
+		}{},
+		&struct {
+			V []*regexp.Regexp `env:"V,csv"`
+		}{},
+		&struct {
+			V map[string]*regexp.Regexp `env:"V"`
+		}{},
+	}
+}
+
+func FuzzRetrieve(f *testing.F) {
+	// At least one seed per combination of options
+	for _, seed := range []string{
+		"", "aGVsbG8=", "H4sIAAAAAAAA/w==", "aGk=,Ymll", "0011223344556677",
+		`{"A":1,"B":"b"}`, "[1,2,3]", "1,b", `1,"2",,3`, "🙂", "0x1f", "250ms",
+		"1.5GiB", "Debg", "${V}", "-2", "abc", "a,b,c,d",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		p := mapToParser(map[string]string{"V": value})
+
+		// Only errors are acceptable, never panics
+		for _, config := range fuzzRetrieveConfigs() {
+			_ = p.Get(config)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package libconfig
+
+import "sync/atomic"
+
+// Holder holds a config struct of type T that can be reloaded while being read
+// concurrently. Each Reload parses a fresh T and atomically swaps it in.
+type Holder[T any] struct {
+	parser *Parser
+	value  atomic.Pointer[T]
+}
+
+// NewHolder creates a Holder that uses the given Parser. The Holder is empty, so
+// Get returns the zero value of T, until Reload succeeds.
+func NewHolder[T any](p *Parser) *Holder[T] {
+	return &Holder[T]{
+		parser: p,
+	}
+}
+
+// Reload parses a fresh config and, if parsing succeeds, swaps it in. On failure
+// the previous config is left intact and the error is returned.
+func (h *Holder[T]) Reload() error {
+	config := new(T)
+	err := h.parser.Get(config)
+	if err != nil {
+		return err
+	}
+
+	h.value.Store(config)
+
+	return nil
+}
+
+// Get returns a copy of the current config
+func (h *Holder[T]) Get() T {
+	config := h.value.Load()
+	if config == nil {
+		var zero T
+		return zero
+	}
+
+	return *config
+}
@@ -0,0 +1,96 @@
+package libconfig_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type holderConfig struct {
+	VarA string `env:"VAR_A"`
+	VarB int    `env:"VAR_B"`
+}
+
+func TestHolderReload(t *testing.T) {
+	envs := map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "1",
+	}
+	p := mapToParser(envs)
+	h := libconfig.NewHolder[holderConfig](&p)
+
+	require := require.New(t)
+	require.Equal(holderConfig{}, h.Get(), "Get should return the zero value before Reload")
+
+	err := h.Reload()
+	require.NoError(err, "Reload should not fail")
+	require.Equal(holderConfig{"VAL_A", 1}, h.Get(), "Get should return the loaded config")
+
+	envs["VAR_B"] = "2"
+	err = h.Reload()
+	require.NoError(err, "Reload should not fail")
+	require.Equal(holderConfig{"VAL_A", 2}, h.Get(), "Get should return the reloaded config")
+}
+
+func TestHolderReloadFailure(t *testing.T) {
+	envs := map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "1",
+	}
+	p := mapToParser(envs)
+	h := libconfig.NewHolder[holderConfig](&p)
+
+	require := require.New(t)
+	err := h.Reload()
+	require.NoError(err, "Reload should not fail")
+
+	envs["VAR_B"] = "not-an-int"
+	err = h.Reload()
+	require.Error(err, "Reload should fail")
+	require.Equal(holderConfig{"VAL_A", 1}, h.Get(), "Get should return the previous config")
+}
+
+func TestHolderConcurrentReload(t *testing.T) {
+	var mu sync.Mutex
+	count := 0
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn: func(name string) (string, bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			count++
+			return strconv.Itoa(count), true
+		},
+	}
+	h := libconfig.NewHolder[holderConfig](&p)
+
+	var incomplete int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = h.Reload()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c := h.Get()
+				if c.VarA != "" && c.VarB == 0 {
+					atomic.AddInt32(&incomplete, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Zero(t, incomplete, "a loaded config should always be complete")
+	require.NotEqual(t, holderConfig{}, h.Get(), "Get should return a loaded config")
+}
@@ -0,0 +1,94 @@
+package libconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// indexedPrefix returns the prefix for the fields of the element at index i of a slice
+// tagged with indexed, e.g. SERVER_0_
+func indexedPrefix(name string, i int) string {
+	return name + "_" + strconv.Itoa(i) + "_"
+}
+
+// probeIndex returns the names of the variables consumed by the struct type with the
+// given prefix and whether any of them is found
+func (p *Parser) probeIndex(ctx context.Context, t reflect.Type, prefix string) (map[string]bool, bool, error) {
+	set := map[string]bool{}
+	err := p.names(t, prefix, set)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Look up the names in order so that the lookups are predictable
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, found, err := p.lookupVar(ctx, name)
+		if err != nil || found {
+			return set, found, err
+		}
+	}
+
+	return set, false, nil
+}
+
+// parseIndexed populates a slice of structs tagged with indexed from the variables
+// NAME_0_*, NAME_1_*, and so on. An element exists if any of its variables is found,
+// and the slice ends at the first index for which none are found, even if later ones
+// exist. If no elements exist, the slice is left unchanged.
+//
+// The path is the path of the slice from the config, e.g. "Servers", to which the
+// index is appended for errors for the fields of each element.
+func (p *Parser) parseIndexed(state *getState, v reflect.Value, tag tagData, path string) error {
+	elemType := v.Type().Elem()
+	slice := reflect.MakeSlice(v.Type(), 0, 0)
+
+	for i := 0; ; i++ {
+		prefix := indexedPrefix(tag.Name, i)
+
+		_, found, err := p.probeIndex(state.ctx, elemType, prefix)
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+
+		elem := reflect.New(elemType).Elem()
+		_, err = p.parse(state, elem, prefix, fmt.Sprintf("%s[%d].", path, i))
+		if err != nil {
+			return err
+		}
+
+		slice = reflect.Append(slice, elem)
+	}
+
+	if slice.Len() > 0 {
+		v.Set(slice)
+	}
+
+	return nil
+}
+
+// indexedNames adds the names of the variables consumed by each element of a slice
+// tagged with indexed to the set, following the same rules as parseIndexed
+func (p *Parser) indexedNames(t reflect.Type, name string, set map[string]bool) error {
+	for i := 0; ; i++ {
+		names, found, err := p.probeIndex(context.Background(), t, indexedPrefix(name, i))
+		if err != nil || !found {
+			return err
+		}
+
+		for n := range names {
+			set[n] = true
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package libconfig_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type indexedServer struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT,optional"`
+}
+
+func TestIndexed(t *testing.T) {
+	type Config struct {
+		Servers []indexedServer `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVER_0_HOST": "a.example.com",
+		"SERVER_0_PORT": "80",
+		"SERVER_1_HOST": "b.example.com",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := []indexedServer{
+		{Host: "a.example.com", Port: 80},
+		{Host: "b.example.com"},
+	}
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.Servers, "Servers should contain both elements")
+}
+
+func TestIndexedGap(t *testing.T) {
+	type Config struct {
+		Servers []indexedServer `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVER_0_HOST": "a.example.com",
+		"SERVER_2_HOST": "c.example.com",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]indexedServer{{Host: "a.example.com"}}, config.Servers, "Servers should stop at the gap")
+
+	p.EnumFn = func() []string {
+		return []string{"SERVER_0_HOST", "SERVER_2_HOST"}
+	}
+	unused, err := p.UnusedVars(&config)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"SERVER_2_HOST"}, unused, "the element after the gap should be unused")
+}
+
+func TestIndexedMissingRequiredField(t *testing.T) {
+	type Config struct {
+		Servers []indexedServer `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVER_0_HOST": "a.example.com",
+		"SERVER_1_PORT": "80",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrField("Servers[1].Host", libconfig.NewErrVarNotFound("SERVER_1_HOST"))
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the second server has no host")
+}
+
+func TestIndexedNoneFound(t *testing.T) {
+	type Config struct {
+		Servers []indexedServer `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(nil)
+
+	defaults := []indexedServer{{Host: "localhost"}}
+	config := Config{
+		Servers: defaults,
+	}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(defaults, config.Servers, "Servers should keep its default")
+}
+
+func TestIndexedWithPrefix(t *testing.T) {
+	type Cluster struct {
+		Servers []indexedServer `env:"SERVER,indexed"`
+	}
+	type Config struct {
+		Cluster Cluster `env:"CLUSTER_,prefix"`
+	}
+
+	p := mapToParser(map[string]string{
+		"APP_CLUSTER_SERVER_0_HOST": "a.example.com",
+		"APP_CLUSTER_SERVER_1_HOST": "b.example.com",
+		"APP_CLUSTER_SERVER_1_PORT": "443",
+	})
+	p.Prefix = "APP_"
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := []indexedServer{
+		{Host: "a.example.com"},
+		{Host: "b.example.com", Port: 443},
+	}
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.Cluster.Servers, "Servers should use the accumulated prefix")
+
+	sources, err := p.GetSources(&config)
+	require.NoError(err, "GetSources should not fail")
+	require.Equal(map[string]string{
+		"Cluster.Servers[0].Host": "APP_CLUSTER_SERVER_0_HOST",
+		"Cluster.Servers[1].Host": "APP_CLUSTER_SERVER_1_HOST",
+		"Cluster.Servers[1].Port": "APP_CLUSTER_SERVER_1_PORT",
+	}, sources, "GetSources should index the paths")
+}
+
+func TestIndexedWithPlan(t *testing.T) {
+	type Config struct {
+		Servers []indexedServer `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVER_0_HOST": "a.example.com",
+	})
+
+	plan, err := p.PlanFor(reflect.TypeOf(Config{}))
+
+	require := require.New(t)
+	require.NoError(err, "PlanFor should not fail")
+
+	config := Config{}
+	err = p.GetWithPlan(plan, &config)
+	require.NoError(err, "GetWithPlan should not fail")
+	require.Equal([]indexedServer{{Host: "a.example.com"}}, config.Servers, "Servers should be populated")
+}
+
+func TestIndexedInvalidType(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOST,indexed"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("HOST,indexed", "indexed")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because indexed requires a slice of structs")
+}
+
+func TestIndexedWithOtherOptions(t *testing.T) {
+	type Config struct {
+		Servers []indexedServer `env:"SERVER,indexed,optional"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("SERVER,indexed,optional", "indexed")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because indexed cannot be combined")
+}
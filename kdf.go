@@ -0,0 +1,45 @@
+package libconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// KDFFunc derives a fixed-length key from a passphrase and a salt, e.g. using
+// scrypt or pbkdf2
+type KDFFunc func(passphrase, salt []byte) ([]byte, error)
+
+// errKDFNotAllowed is the cause of the ErrDecodeFailure returned for a field tagged
+// with kdf when the Parser does not allow key derivation
+var errKDFNotAllowed = errors.New("key derivation is not allowed by the parser")
+
+// derive derives a key from the passphrase using the KDF named in the tag and the
+// salt found in the variable named in the tag. The passphrase is a secret, so its
+// errors hold Redacted rather than the value.
+func (p *Parser) derive(ctx context.Context, tag tagData, passphrase []byte) ([]byte, error) {
+	if !p.AllowKDF {
+		return nil, NewErrDecodeFailure(errKDFNotAllowed, tag.Name, Redacted, "kdf")
+	}
+
+	fn, ok := p.KDFs[tag.KDF]
+	if !ok {
+		err := fmt.Errorf("no KDF registered as [%s]", tag.KDF)
+		return nil, NewErrDecodeFailure(err, tag.Name, Redacted, "kdf")
+	}
+
+	salt, found, err := p.lookupVar(ctx, tag.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, NewErrVarNotFound(tag.Salt)
+	}
+
+	key, err := fn(passphrase, []byte(salt))
+	if err != nil {
+		return nil, NewErrDecodeFailure(err, tag.Name, Redacted, "kdf")
+	}
+
+	return key, nil
+}
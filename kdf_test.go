@@ -0,0 +1,163 @@
+package libconfig_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+// sha256KDF is a stand-in for a real KDF such as scrypt
+func sha256KDF(passphrase, salt []byte) ([]byte, error) {
+	key := sha256.Sum256(append(salt, passphrase...))
+	return key[:], nil
+}
+
+func TestKDF(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PASSPHRASE": "correct horse battery staple",
+		"KEY_SALT":   "salt",
+	})
+	p.AllowKDF = true
+	p.KDFs = map[string]libconfig.KDFFunc{
+		"sha256": sha256KDF,
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+	expected, _ := sha256KDF([]byte("correct horse battery staple"), []byte("salt"))
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.Key, "Key should be derived from the passphrase")
+	require.Len(config.Key, 32, "Key should have a fixed length")
+}
+
+func TestKDFNotAllowed(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PASSPHRASE": "passphrase",
+		"KEY_SALT":   "salt",
+	})
+	p.KDFs = map[string]libconfig.KDFFunc{
+		"sha256": sha256KDF,
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because AllowKDF is not set")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("kdf", specificErr.Type, "the error should be for the kdf")
+	require.NotContains(err.Error(), "passphrase", "the error should not reveal the passphrase")
+	require.Nil(config.Key, "Key should not be set")
+}
+
+func TestKDFNotRegistered(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=scrypt,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PASSPHRASE": "passphrase",
+		"KEY_SALT":   "salt",
+	})
+	p.AllowKDF = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because no KDF is registered")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("kdf", specificErr.Type, "the error should be for the kdf")
+	require.NotContains(err.Error(), "passphrase", "the error should not reveal the passphrase")
+}
+
+func TestKDFFailure(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=broken,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PASSPHRASE": "passphrase",
+		"KEY_SALT":   "salt",
+	})
+	p.AllowKDF = true
+	cause := fmt.Errorf("some error")
+	p.KDFs = map[string]libconfig.KDFFunc{
+		"broken": func(passphrase, salt []byte) ([]byte, error) {
+			return nil, cause
+		},
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrDecodeFailure(cause, "PASSPHRASE", libconfig.Redacted, "kdf")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail with the KDF error")
+	require.Equal(cause, errors.Cause(err), "the KDF error should be the cause")
+	require.NotContains(err.Error(), "passphrase", "the error should not reveal the passphrase")
+}
+
+func TestKDFSaltMissing(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PASSPHRASE": "passphrase",
+	})
+	p.AllowKDF = true
+	p.KDFs = map[string]libconfig.KDFFunc{
+		"sha256": sha256KDF,
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("KEY_SALT")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the salt is missing")
+}
+
+func TestKDFWithoutSalt(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=sha256"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("PASSPHRASE,kdf=sha256", "kdf")
+
+	require := require.New(t)
+	require.Equal(expected, err, "kdf should require salt")
+}
+
+func TestKDFNotBytes(t *testing.T) {
+	type Config struct {
+		Key string `env:"PASSPHRASE,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("PASSPHRASE,kdf=sha256,salt=KEY_SALT", "kdf=sha256")
+
+	require := require.New(t)
+	require.Equal(expected, err, "kdf should only apply to []byte")
+}
@@ -0,0 +1,106 @@
+package libconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// parseKV parses comma-separated key=value pairs, e.g. "host=localhost,port=5432", into
+// the fields of the struct v. Within a struct tagged with kv, the name in the tag of
+// each field is its key rather than the name of a variable, and the options that decode
+// and validate a value apply as usual, e.g. optional, default, base64, or oneof. Errors
+// for a value identify its key, e.g. DB[port].
+func (p *Parser) parseKV(state *getState, v reflect.Value, tag tagData, value string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	fields, err := p.fields(v.Type(), "")
+	if err != nil {
+		return err
+	}
+
+	// keys holds the keys in the order they are given, so that errors are consistent
+	var keys []string
+	entries := map[string]string{}
+	if value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			key, raw, ok := strings.Cut(entry, "=")
+			if !ok {
+				err := fmt.Errorf("entry [%s] is not of the form key=value", entry)
+				return NewErrDecodeFailure(err, tag.Name, value, "kv")
+			}
+			key = strings.TrimSpace(key)
+			keys = append(keys, key)
+			entries[key] = strings.TrimSpace(raw)
+		}
+	}
+
+	for _, f := range fields {
+		fieldTag := f.Tag
+		if !fieldTag.Tagged || f.Field.PkgPath != "" {
+			continue
+		}
+
+		key := fieldTag.Name
+		raw, found := entries[key]
+		delete(entries, key)
+		if !found && fieldTag.HasDefault {
+			raw, found = fieldTag.Default, true
+		}
+		if !found {
+			if !fieldTag.Optional {
+				err := fmt.Errorf("key [%s] is missing", key)
+				return NewErrDecodeFailure(err, tag.Name, value, "kv")
+			}
+			continue
+		}
+
+		fieldTag.Name = tag.Name + "[" + key + "]"
+		err = p.assignKey(state, v.Field(f.Index), fieldTag, raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Any remaining key does not belong to a field, e.g. because it is misspelled
+	for _, key := range keys {
+		if _, ok := entries[key]; ok {
+			err := fmt.Errorf("key [%s] does not match any field", key)
+			return NewErrDecodeFailure(err, tag.Name, value, "kv")
+		}
+	}
+
+	return nil
+}
+
+// assignKey sets the field for a single key, decoding and validating the value exactly
+// like the value of a variable. As in a map, a time.Duration is always parsed as such.
+func (p *Parser) assignKey(state *getState, v reflect.Value, tag tagData, value string) error {
+	if isDuration(v.Type()) {
+		tag.Unit = true
+	}
+
+	err := p.assign(state, v, tag, value)
+	if err != nil {
+		return err
+	}
+
+	// A value decoded as a whole may check its own invariants
+	if tag.JSON || tag.Unmarshaler != "" {
+		err = validateDecoded(v, tag.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tag.Lower || tag.Upper {
+		changeCase(v, tag)
+	}
+
+	return validate(v, tag)
+}
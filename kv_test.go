@@ -0,0 +1,199 @@
+package libconfig_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type kvDatabase struct {
+	Host    string        `env:"host"`
+	Port    int           `env:"port"`
+	Timeout time.Duration `env:"timeout,default=5s"`
+	User    *string       `env:"user,optional"`
+}
+
+func TestKV(t *testing.T) {
+	type Config struct {
+		DB      kvDatabase  `env:"DB,kv"`
+		Replica *kvDatabase `env:"REPLICA,kv,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=localhost, port=5432",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(kvDatabase{
+		Host:    "localhost",
+		Port:    5432,
+		Timeout: 5 * time.Second,
+	}, config.DB, "the keys should populate the fields of DB")
+	require.Nil(config.Replica, "Replica should stay nil because it is not set")
+}
+
+func TestKVNotNestedTags(t *testing.T) {
+	type Config struct {
+		DB *kvDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB":   "host=db,port=1,user=admin",
+		"host": "not a variable",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail with ErrNestedTags because the inner tags are keys")
+	require.Equal("db", config.DB.Host, "Host should come from the key, not a variable")
+	require.Equal("admin", *config.DB.User, "User should be allocated and set")
+	require.NoError(p.Check(&config), "Check should not fail either")
+}
+
+func TestKVMissingKey(t *testing.T) {
+	type Config struct {
+		DB kvDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=localhost",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrDecodeFailure(errors.New("key [port] is missing"), "DB", "host=localhost", "kv")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because port is required")
+}
+
+func TestKVUnknownKey(t *testing.T) {
+	type Config struct {
+		DB kvDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=localhost,port=1,prot=2",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrDecodeFailure(errors.New("key [prot] does not match any field"), "DB", "host=localhost,port=1,prot=2", "kv")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because prot is misspelled")
+}
+
+func TestKVInvalidValue(t *testing.T) {
+	type Config struct {
+		DB kvDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=localhost,port=http",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "Get should fail because port is not a number")
+	require.Equal("DB[port]", err.(*libconfig.ErrCannotParseEnv).Key, "the error should identify the key")
+}
+
+func TestKVInvalidType(t *testing.T) {
+	type Config struct {
+		DB string `env:"DB,kv"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("DB,kv", "kv")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because only structs hold keys")
+}
+
+type kvValidatedDatabase struct {
+	Host     string `env:"host,oneof=a|b"`
+	Port     int    `env:"port,min=1,max=65535"`
+	Password []byte `env:"password,base64,optional"`
+}
+
+func TestKVValidation(t *testing.T) {
+	type Config struct {
+		DB kvValidatedDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=b,port=5432,password=c2VjcmV0",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail because every value is valid")
+	require.Equal(kvValidatedDatabase{
+		Host:     "b",
+		Port:     5432,
+		Password: []byte("secret"),
+	}, config.DB, "the values should be decoded like variables")
+	require.NoError(p.Check(&config), "Check should not fail because the options apply to keys")
+}
+
+func TestKVNotInEnum(t *testing.T) {
+	type Config struct {
+		DB kvValidatedDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=zzz,port=5432",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrNotInEnum("DB[host]", "zzz", []string{"a", "b"})
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because host is not one of the allowed values")
+}
+
+func TestKVOutOfRange(t *testing.T) {
+	type Config struct {
+		DB kvValidatedDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=a,port=0",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOutOfRange("DB[port]", "0", "1", "65535")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because port is below the minimum")
+}
+
+func TestKVInvalidBase64(t *testing.T) {
+	type Config struct {
+		DB kvValidatedDatabase `env:"DB,kv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": "host=a,port=1,password=!!!",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	var specificErr *libconfig.ErrDecodeFailure
+	require.ErrorAs(err, &specificErr, "Get should fail because password is not base64")
+	require.Equal("DB[password]", specificErr.Key, "the error should identify the key")
+}
@@ -0,0 +1,33 @@
+package libconfig
+
+// LazyDefaultFunc computes the value of a required variable that is missing, e.g. by
+// fetching it from a remote service. It is only called if the value is needed.
+type LazyDefaultFunc func() (string, error)
+
+// RegisterLazyDefault registers a function that supplies the value of the required
+// variable with the given name, including any prefix, if it is not found and has no
+// other default. The value is decoded exactly like a value that was found. An error
+// from the function is wrapped in ErrDefaultFailed.
+func (p *Parser) RegisterLazyDefault(name string, fn LazyDefaultFunc) {
+	if p.lazyDefaults == nil {
+		p.lazyDefaults = make(map[string]LazyDefaultFunc)
+	}
+
+	p.lazyDefaults[name] = fn
+}
+
+// lazyDefault calls the function registered for the variable, if any. It returns false
+// if no function is registered.
+func (p *Parser) lazyDefault(name string) (string, bool, error) {
+	fn, ok := p.lazyDefaults[name]
+	if !ok {
+		return "", false, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return "", false, NewErrDefaultFailed(name, err)
+	}
+
+	return value, true, nil
+}
@@ -1,15 +1,64 @@
 package libconfig
 
-import "os"
+import (
+	"os"
+	"strings"
+)
 
 // lc is the default Parser for basic use.
 // It uses "env" as the tag and `os.LookupEnv` for the lookup function.
 var lc = Parser{
 	Tag:      "env",
 	LookupFn: os.LookupEnv,
+	EnumFn:   environNames,
 }
 
 // Get populates the config struct with values from the environment
 func Get(config interface{}) error {
 	return lc.Get(config)
 }
+
+// Check validates the tags of the config struct without consulting the environment
+func Check(config interface{}) error {
+	return lc.Check(config)
+}
+
+// UnusedVars returns the names of the variables in the environment that are not
+// consumed by the config struct. Since the default Parser has no Prefix, this includes
+// every unrelated variable, e.g. PATH, so a Parser with a Prefix is usually more useful.
+func UnusedVars(config interface{}) ([]string, error) {
+	return lc.UnusedVars(config)
+}
+
+// NewMapParser creates a Parser that looks up variables in the map rather than the
+// environment, which is convenient for tests. Keys that are present are found, even if
+// their value is empty. The map is not copied, so later changes to it are visible to
+// the Parser.
+func NewMapParser(m map[string]string, tag string) *Parser {
+	return &Parser{
+		Tag: tag,
+		LookupFn: func(name string) (string, bool) {
+			value, found := m[name]
+			return value, found
+		},
+		EnumFn: func() []string {
+			names := make([]string, 0, len(m))
+			for name := range m {
+				names = append(names, name)
+			}
+
+			return names
+		},
+	}
+}
+
+// environNames lists the names of the variables in the environment
+func environNames() []string {
+	env := os.Environ()
+	names := make([]string, 0, len(env))
+	for _, kv := range env {
+		names = append(names, strings.SplitN(kv, "=", 2)[0])
+	}
+
+	return names
+}
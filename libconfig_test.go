@@ -1,8 +1,23 @@
 package libconfig_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -68,92 +83,116 @@ func TestTagMissingName(t *testing.T) {
 	require.Equal(expected, err, "Get should fail")
 }
 
-func TestString(t *testing.T) {
+func TestAutoName(t *testing.T) {
+	type Database struct {
+		Host string `env:""`
+	}
 	type Config struct {
-		VarA string `env:"VAR_A"`
+		MaxConns int    `env:""`
+		HTTPPort int    `env:",optional"`
+		UserID   string `env:",optional"`
+		Explicit string `env:"EXPLICIT_NAME"`
+		Database `env:",prefix"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
+		"MAX_CONNS":     "10",
+		"HTTP_PORT":     "8080",
+		"USER_ID":       "u1",
+		"EXPLICIT_NAME": "explicit",
+		"DATABASE_HOST": "localhost",
 	})
+	p.AutoName = true
 
 	config := Config{}
 	err := p.Get(&config)
 
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
-	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal(10, config.MaxConns, "MaxConns should be read from MAX_CONNS")
+	require.Equal(8080, config.HTTPPort, "HTTPPort should be read from HTTP_PORT")
+	require.Equal("u1", config.UserID, "UserID should be read from USER_ID")
+	require.Equal("explicit", config.Explicit, "Explicit should keep its name")
+	require.Equal("localhost", config.Database.Host, "the derived prefix should end in an underscore")
 }
 
-func TestStringRequiredButMissing(t *testing.T) {
+func TestAutoNameFunc(t *testing.T) {
 	type Config struct {
-		VarA string `env:"VAR_A"`
+		MaxConns int `env:""`
 	}
 
-	p := mapToParser(nil)
+	p := mapToParser(map[string]string{
+		"maxconns": "10",
+	})
+	p.AutoName = true
+	p.NameFunc = strings.ToLower
+
 	config := Config{}
 	err := p.Get(&config)
-	expected := libconfig.NewErrVarNotFound("VAR_A")
 
 	require := require.New(t)
-	require.Equal(expected, err, "Get should fail because VAR_A is not available")
+	require.NoError(err, "Get should not fail")
+	require.Equal(10, config.MaxConns, "MaxConns should be read using the NameFunc")
 }
 
-func TestStringOptionalAndMissing(t *testing.T) {
+func TestAutoNameChangedAfterGet(t *testing.T) {
 	type Config struct {
-		VarA string `env:"VAR_A"`
-		VarB string `env:"VAR_B,optional"`
+		MaxConns int `env:""`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
+		"MAX_CONNS": "10",
 	})
-	config := Config{}
-	err := p.Get(&config)
-
-	require := require.New(t)
-	require.NoError(err, "Get not should because VAR_B is marked as optional")
-}
+	p.AutoName = true
 
-func TestBadOption(t *testing.T) {
-	type Config struct {
-		VarA string `env:"VAR_A,not-a-valid-option"`
-	}
-
-	p := mapToParser(nil)
 	config := Config{}
 	err := p.Get(&config)
-	expected := libconfig.NewErrInvalidTagOption("VAR_A,not-a-valid-option", "not-a-valid-option")
 
 	require := require.New(t)
-	require.Equal(expected, err, "Get not should because VAR_B is marked as optional")
+	require.NoError(err, "Get should not fail")
+	require.Equal(10, config.MaxConns, "MaxConns should be read from MAX_CONNS")
+
+	p.AutoName = false
+	err = p.Get(&Config{})
+	require.Equal(libconfig.NewErrMissingNameTag(""), err, "Get should use the new setting even though the tags are cached")
 }
 
-func TestByteSlice(t *testing.T) {
+func TestAutoNameFuncChangedAfterGet(t *testing.T) {
 	type Config struct {
-		VarA []byte `env:"VAR_A"`
+		MaxConns int `env:""`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
+		"MAX_CONNS": "10",
+		"maxconns":  "20",
 	})
+	p.AutoName = true
 
-	expected := []byte{'V', 'A', 'L', '_', 'A'}
 	config := Config{}
 	err := p.Get(&config)
 
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
-	require.Equal(expected, config.VarA, "VarA should parse correctly")
+	require.Equal(10, config.MaxConns, "MaxConns should be read from MAX_CONNS")
+
+	p.NameFunc = strings.ToLower
+	config = Config{}
+	err = p.Get(&config)
+	require.NoError(err, "Get should not fail")
+	require.Equal(20, config.MaxConns, "Get should use the new NameFunc even though the tags are cached")
+
+	plan, err := p.PlanFor(reflect.TypeOf(config))
+	require.NoError(err, "PlanFor should not fail")
+	require.Equal("maxconns", plan.Steps[0].Name, "the plan should use the new NameFunc")
 }
 
-func TestBase64String(t *testing.T) {
+func TestString(t *testing.T) {
 	type Config struct {
-		VarA string `env:"VAR_A,base64"`
+		VarA string `env:"VAR_A"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VkFMX0E=",
+		"VAR_A": "VAL_A",
 	})
 
 	config := Config{}
@@ -164,641 +203,669 @@ func TestBase64String(t *testing.T) {
 	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
 }
 
-func TestBase64ByteSlice(t *testing.T) {
+func TestStringRequiredButMissing(t *testing.T) {
 	type Config struct {
-		VarA []byte `env:"VAR_A,base64"`
+		VarA string `env:"VAR_A"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "VkFMX0E=",
-	})
-
-	expected := []byte{'V', 'A', 'L', '_', 'A'}
+	p := mapToParser(nil)
 	config := Config{}
 	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("VAR_A")
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(expected, config.VarA, "VarA should parse correctly")
+	require.Equal(expected, err, "Get should fail because VAR_A is not available")
 }
 
-func TestBase64Int(t *testing.T) {
+func TestStringOptionalAndMissing(t *testing.T) {
 	type Config struct {
-		VarA int `env:"VAR_A,base64"`
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,optional"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "MDkxNQ==",
+		"VAR_A": "VAL_A",
 	})
-
 	config := Config{}
 	err := p.Get(&config)
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(915, config.VarA, "VarA should parse correctly")
+	require.NoError(err, "Get not should because VAR_B is marked as optional")
 }
 
-func TestBase64Invalid(t *testing.T) {
+func TestEmptyAsUnsetOptional(t *testing.T) {
 	type Config struct {
-		VarA string `env:"VAR_A,base64"`
+		VarA string `env:"VAR_A,optional,emptyasunset"`
+		VarB int    `env:"VAR_B,optional,emptyasunset"`
+		VarC string `env:"VAR_C,optional"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "i-am-not-base64",
+		"VAR_A": "",
+		"VAR_B": "",
+		"VAR_C": "",
 	})
-
-	config := Config{}
+	config := Config{
+		VarA: "default",
+		VarB: 42,
+		VarC: "default",
+	}
 	err := p.Get(&config)
-	// Note that we do not actually expect a nil error.
-	// We care (and test below) that an error is present, but not the error itself.
-	expected := libconfig.NewErrDecodeFailure(nil, "VAR_A", "i-am-not-base64", "base64")
 
 	require := require.New(t)
-	require.Error(err, "Get should fail to parse the value as the base64")
-	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
-	require.True(ok, "the error should be ErrDecodeFailure")
-	require.Error(specificErr.Because, "Because should be set")
-	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
-	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+	require.NoError(err, "Get should not fail because empty values are treated as unset")
+	require.Equal("default", config.VarA, "VarA should keep its default")
+	require.Equal(42, config.VarB, "VarB should keep its default")
+	require.Equal("", config.VarC, "VarC should be set to the empty value")
 }
 
-func TestTwoStrings(t *testing.T) {
+func TestDefault(t *testing.T) {
 	type Config struct {
-		VarA string `env:"VAR_A"`
-		VarB string `env:"VAR_B"`
+		VarA string         `env:"VAR_A,default=VAL_A"`
+		VarB int            `env:"VAR_B,default=42"`
+		VarC string         `env:"VAR_C,default=a,b"`
+		VarD string         `env:"VAR_D,default="`
+		VarE string         `env:"VAR_E,default=ignored"`
+		JSON map[string]int `env:"JSON,json,default={\"a\":1,\"b\":2}"`
+		B64  string         `env:"B64,base64,default=VkFM"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
+		"VAR_E": "VAL_E",
 	})
 
-	config := Config{}
+	config := Config{
+		VarD: "preset",
+	}
 	err := p.Get(&config)
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.NoError(err, "Get should not fail because every field has a default")
+	require.Equal("VAL_A", config.VarA, "VarA should use its default")
+	require.Equal(42, config.VarB, "VarB should parse its default")
+	require.Equal("a,b", config.VarC, "VarC should keep the commas in its default")
+	require.Equal("", config.VarD, "VarD should use its empty default")
+	require.Equal("VAL_E", config.VarE, "VarE should prefer the variable")
+	require.Equal(map[string]int{"a": 1, "b": 2}, config.JSON, "JSON should decode its default as json")
+	require.Equal("VAL", config.B64, "B64 should decode its default as base64")
 }
 
-func TestInt(t *testing.T) {
+func TestDefaultInvalid(t *testing.T) {
 	type Config struct {
-		VarA int `env:"VAR_A"`
+		VarA int `env:"VAR_A,min=1,default=0"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "500",
-	})
+	p := mapToParser(nil)
 
 	config := Config{}
 	err := p.Get(&config)
+	expected := libconfig.NewErrOutOfRange("VAR_A", "0", "1", "")
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(500, config.VarA, "VarA should parse correctly")
+	require.Equal(expected, err, "Get should validate the default")
 }
 
-func TestIntPointer(t *testing.T) {
+func TestDefaultFollowedByOption(t *testing.T) {
 	type Config struct {
-		VarA *int `env:"VAR_A"`
+		VarA string `env:"VAR_A,default=a,msg=bad"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "500",
-	})
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,default=a,msg=bad", "default=a")
 
-	config := Config{}
-	err := p.Get(&config)
-	expected := 500
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(&expected, config.VarA, "VarA should parse correctly")
+	require.Equal(expected, err, "Get should fail rather than take msg=bad as part of the default")
 }
 
-func TestStringPointer(t *testing.T) {
+func TestDefaultFollowedByFlag(t *testing.T) {
 	type Config struct {
-		VarA *string `env:"VAR_A"`
+		VarA string `env:"VAR_A,default=a,secret"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "500",
-	})
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,default=a,secret", "default=a")
 
-	config := Config{}
-	err := p.Get(&config)
-	expected := "500"
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(&expected, config.VarA, "VarA should parse correctly")
+	require.Equal(expected, err, "Get should fail rather than take secret as part of the default")
 }
 
-func TestIntOverflow(t *testing.T) {
+func TestDefaultWithEquals(t *testing.T) {
 	type Config struct {
-		VarA int8 `env:"VAR_A"`
+		VarA string `env:"VAR_A,default=a=1,b=2"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "500",
-	})
+	p := mapToParser(nil)
 
 	config := Config{}
 	err := p.Get(&config)
 
-	expected := libconfig.NewErrOverflow(reflect.Int8, "VAR_A", "500")
-
 	require := require.New(t)
-	require.Equal(expected, err, "Get should fail to parse \"500\" as int8")
+	require.NoError(err, "Get should not fail because b= is not an option")
+	require.Equal("a=1,b=2", config.VarA, "VarA should keep the whole default")
 }
 
-func TestIntCannotParseEnv(t *testing.T) {
+func TestDefaultWithEmptyAsUnset(t *testing.T) {
 	type Config struct {
-		VarA int `env:"VAR_A"`
+		VarA string `env:"VAR_A,emptyasunset,default=VAL_A"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "not-an-int",
+		"VAR_A": "",
 	})
 
 	config := Config{}
 	err := p.Get(&config)
-	// Note that we do not actually expect a nil error.
-	// We care (and test below) that an error is present, but not the error itself.
-	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Int, "VAR_A", "not-an-int")
 
 	require := require.New(t)
-	require.Error(err, "Get should fail to parse the value as the kind")
-	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
-	require.True(ok, "the error should be ErrCannotParseEnv")
-	require.Error(specificErr.Because, "Because should be set")
-	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
-	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should use its default because the empty value is unset")
 }
 
-func TestUint(t *testing.T) {
+func TestDefaultFn(t *testing.T) {
 	type Config struct {
-		VarA uint `env:"VAR_A"`
+		Env       string `env:"ENV,default=tag"`
+		DefaultFn string `env:"DEFAULT_FN,default=tag"`
+		Tag       string `env:"TAG,default=tag"`
+		Missing   string `env:"MISSING"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "500",
+		"ENV": "env",
 	})
+	p.DefaultFn = func(key string) (string, bool) {
+		switch key {
+		case "ENV", "DEFAULT_FN":
+			return "defaultfn", true
+		}
+		return "", false
+	}
+	sources := map[string]libconfig.Source{}
+	p.OnSet = func(name string, source libconfig.Source) {
+		sources[name] = source
+	}
 
 	config := Config{}
 	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("MISSING")
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(uint(500), config.VarA, "VarA should parse correctly")
+	require.Equal(expected, err, "Get should fail because MISSING has no value or default")
+	require.Equal("env", config.Env, "the variable should take precedence")
+	require.Equal("defaultfn", config.DefaultFn, "the DefaultFn should take precedence over the tag")
+	require.Equal("tag", config.Tag, "the tag should be the last resort")
+	require.Equal(map[string]libconfig.Source{
+		"ENV":        libconfig.SourceLookup,
+		"DEFAULT_FN": libconfig.SourceDefaultFn,
+		"TAG":        libconfig.SourceDefault,
+	}, sources, "OnSet should report the source of each value")
 }
 
-func TestUintOverflow(t *testing.T) {
+func TestRegisterLazyDefault(t *testing.T) {
 	type Config struct {
-		VarA uint8 `env:"VAR_A"`
+		Present  string `env:"PRESENT"`
+		Missing  int    `env:"MISSING"`
+		Optional string `env:"OPTIONAL,optional"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "500",
+		"PRESENT": "env",
 	})
 
+	calls := []string{}
+	for _, name := range []string{"PRESENT", "MISSING", "OPTIONAL"} {
+		name := name
+		p.RegisterLazyDefault(name, func() (string, error) {
+			calls = append(calls, name)
+			return "42", nil
+		})
+	}
+	sources := map[string]libconfig.Source{}
+	p.OnSet = func(name string, source libconfig.Source) {
+		sources[name] = source
+	}
+
 	config := Config{}
 	err := p.Get(&config)
 
-	expected := libconfig.NewErrOverflow(reflect.Uint8, "VAR_A", "500")
-
 	require := require.New(t)
-	require.Equal(expected, err, "Get should fail to parse \"500\" as uint8")
+	require.NoError(err, "Get should not fail")
+	require.Equal("env", config.Present, "the variable should take precedence")
+	require.Equal(42, config.Missing, "the lazy default should be decoded")
+	require.Equal("", config.Optional, "optional fields should not use lazy defaults")
+	require.Equal([]string{"MISSING"}, calls, "the lazy default should only be called for the missing variable")
+	require.Equal(libconfig.SourceLazyDefault, sources["MISSING"], "OnSet should report the lazy default")
 }
 
-func TestUintCannotParseEnv(t *testing.T) {
+func TestRegisterLazyDefaultFailure(t *testing.T) {
 	type Config struct {
-		VarA uint `env:"VAR_A"`
+		Missing string `env:"MISSING"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "not-a-uint",
+	p := mapToParser(nil)
+	cause := errors.New("remote unavailable")
+	p.RegisterLazyDefault("MISSING", func() (string, error) {
+		return "", cause
 	})
 
 	config := Config{}
 	err := p.Get(&config)
-	// Note that we do not actually expect a nil error.
-	// We care (and test below) that an error is present, but not the error itself.
-	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Uint, "VAR_A", "not-a-uint")
+	expected := libconfig.NewErrDefaultFailed("MISSING", cause)
 
 	require := require.New(t)
-	require.Error(err, "Get should fail to parse the value as the kind")
-	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
-	require.True(ok, "the error should be ErrCannotParseEnv")
-	require.Error(specificErr.Because, "Because should be set")
-	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
-	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+	require.Equal(expected, err, "the error from the lazy default should be wrapped")
+	require.True(errors.Is(err, cause), "the cause should be accessible")
 }
 
-func TestFloat32(t *testing.T) {
+func TestGetResolved(t *testing.T) {
 	type Config struct {
-		VarA float32 `env:"VAR_A"`
+		Env       string `env:"ENV"`
+		Alt       string `env:"ALT,alt=OLD_ALT"`
+		DefaultFn string `env:"DEFAULT_FN,default=tag"`
+		Tag       string `env:"TAG,default=tag"`
+		Lazy      string `env:"LAZY"`
+		Optional  string `env:"OPTIONAL,optional"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "500.5",
+		"ENV":     "env",
+		"OLD_ALT": "alt",
+	})
+	p.DefaultFn = func(key string) (string, bool) {
+		return "defaultfn", key == "DEFAULT_FN"
+	}
+	p.RegisterLazyDefault("LAZY", func() (string, error) {
+		return "lazy", nil
 	})
 
 	config := Config{}
-	err := p.Get(&config)
+	resolved, err := p.GetResolved(&config)
+	expected := map[string]libconfig.Resolution{
+		"ENV":        {Name: "ENV", Source: libconfig.SourceLookup},
+		"ALT":        {Name: "OLD_ALT", Source: libconfig.SourceAlt},
+		"DEFAULT_FN": {Name: "DEFAULT_FN", Source: libconfig.SourceDefaultFn},
+		"TAG":        {Name: "TAG", Source: libconfig.SourceDefault},
+		"LAZY":       {Name: "LAZY", Source: libconfig.SourceLazyDefault},
+	}
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(float32(500.5), config.VarA, "VarA should parse correctly")
+	require.NoError(err, "GetResolved should not fail")
+	require.Equal(expected, resolved, "each field should report the tier it was resolved from")
+	require.Equal("alt", config.Alt, "the alternate name should be used")
+	require.Equal("defaultfn", config.DefaultFn, "the DefaultFn should take precedence over the tag")
 }
 
-func TestFloat64(t *testing.T) {
+func TestGetResolvedError(t *testing.T) {
 	type Config struct {
-		VarA float64 `env:"VAR_A"`
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "500.5",
+		"VAR_A": "VAL_A",
 	})
 
-	config := Config{}
-	err := p.Get(&config)
+	resolved, err := p.GetResolved(&Config{})
+	expected := map[string]libconfig.Resolution{
+		"VAR_A": {Name: "VAR_A", Source: libconfig.SourceLookup},
+	}
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(float64(500.5), config.VarA, "VarA should parse correctly")
+	require.Equal(libconfig.NewErrVarNotFound("VAR_B"), err, "GetResolved should fail because VAR_B is missing")
+	require.Equal(expected, resolved, "fields resolved before the error should be reported")
 }
 
-func TestFloatOverflow(t *testing.T) {
+func TestEmptyAsUnsetRequired(t *testing.T) {
 	type Config struct {
-		VarA float32 `env:"VAR_A"`
+		VarA string `env:"VAR_A,emptyasunset"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "1003006009012015018021024027030033036039",
+		"VAR_A": "",
 	})
-
 	config := Config{}
 	err := p.Get(&config)
-
-	expected := libconfig.NewErrOverflow(reflect.Float32, "VAR_A", "1003006009012015018021024027030033036039")
+	expected := libconfig.NewErrVarNotFound("VAR_A")
 
 	require := require.New(t)
-	require.Equal(expected, err, "Get should fail to parse \"1003006009012015018021024027030033036039\" as float32")
+	require.Equal(expected, err, "Get should fail because VAR_A is empty")
 }
 
-func TestFloatCannotParseEnv(t *testing.T) {
+func TestEmptyAsUnsetValidatesDefault(t *testing.T) {
 	type Config struct {
-		VarA float64 `env:"VAR_A"`
+		VarA string `env:"VAR_A,optional,emptyasunset,oneof=a|b"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "not-a-float",
+		"VAR_A": "",
 	})
-
-	config := Config{}
+	config := Config{
+		VarA: "c",
+	}
 	err := p.Get(&config)
-	// Note that we do not actually expect a nil error.
-	// We care (and test below) that an error is present, but not the error itself.
-	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Float64, "VAR_A", "not-a-float")
+	expected := libconfig.NewErrNotInEnum("VAR_A", "c", []string{"a", "b"})
 
 	require := require.New(t)
-	require.Error(err, "Get should fail to parse the value as the kind")
-	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
-	require.True(ok, "the error should be ErrCannotParseEnv")
-	require.Error(specificErr.Because, "Because should be set")
-	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
-	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+	require.Equal(expected, err, "Get should fail because the kept default is not allowed")
 }
 
-func TestBoolTrue(t *testing.T) {
+func TestAlt(t *testing.T) {
 	type Config struct {
-		VarA bool `env:"VAR_A"`
+		VarA string `env:"VAR_A,alt=OLD_A"`
+		VarB string `env:"VAR_B,alt=OLD_B|OLDER_B"`
+		VarC string `env:"VAR_C,alt=OLD_C,alt=OLDER_C"`
+		VarD string `env:"VAR_D,optional,alt=OLD_D"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "true",
+		"VAR_A":   "VAL_A",
+		"OLD_A":   "OLD_A",
+		"OLDER_B": "OLDER_B",
+		"OLD_C":   "OLD_C",
+		"OLDER_C": "OLDER_C",
 	})
-
-	config := Config{}
+	config := Config{
+		VarD: "default",
+	}
 	err := p.Get(&config)
 
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
-	require.Equal(true, config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_A", config.VarA, "VarA should prefer the primary name")
+	require.Equal("OLDER_B", config.VarB, "VarB should fall back to the second alternate")
+	require.Equal("OLD_C", config.VarC, "VarC should fall back to the first alternate")
+	require.Equal("default", config.VarD, "VarD should keep its default")
 }
 
-func TestBoolFalse(t *testing.T) {
+func TestAltNotFound(t *testing.T) {
 	type Config struct {
-		VarA bool `env:"VAR_A"`
+		VarA string `env:"VAR_A,alt=OLD_A"`
 	}
 
-	p := mapToParser(map[string]string{
-		"VAR_A": "false",
-	})
-
+	p := mapToParser(nil)
 	config := Config{}
 	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("VAR_A")
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal(false, config.VarA, "VarA should parse correctly")
+	require.Equal(expected, err, "Get should fail with the primary name")
 }
 
-func TestBoolCannotParseEnv(t *testing.T) {
+func TestAltErrorUsesResolvedName(t *testing.T) {
 	type Config struct {
-		VarA bool `env:"VAR_A"`
+		VarA int `env:"VAR_A,alt=OLD_A"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "not-a-bool",
+		"OLD_A": "not-an-int",
 	})
-
 	config := Config{}
 	err := p.Get(&config)
-	// Note that we do not actually expect a nil error.
-	// We care (and test below) that an error is present, but not the error itself.
-	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Bool, "VAR_A", "not-a-bool")
 
 	require := require.New(t)
-	require.Error(err, "Get should fail to parse the value as the kind")
 	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
 	require.True(ok, "the error should be ErrCannotParseEnv")
-	require.Error(specificErr.Because, "Because should be set")
-	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
-	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+	require.Equal("OLD_A", specificErr.Key, "the error should use the name that was found")
 }
-func TestErrCannotSetKindForInterface(t *testing.T) {
+
+func TestAltWithPrefix(t *testing.T) {
 	type Config struct {
-		VarA interface{} `env:"VAR_A"`
+		VarA string `env:"VAR_A,alt=OLD_A"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "false",
+		"APP_OLD_A": "VAL_A",
+		"APP_EXTRA": "",
 	})
+	p.Prefix = "APP_"
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "the alternate should have the prefix")
+
+	unused, err := p.UnusedVars(&config)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"APP_EXTRA"}, unused, "the alternate should be consumed")
+}
 
+func TestBadOption(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,not-a-valid-option"`
+	}
+
+	p := mapToParser(nil)
 	config := Config{}
 	err := p.Get(&config)
-	expected := libconfig.NewErrCannotSetKind(reflect.Interface)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,not-a-valid-option", "not-a-valid-option")
 
 	require := require.New(t)
-	require.Equal(expected, err, "Get should fail to parse reflect.Interface")
+	require.Equal(expected, err, "Get not should because VAR_B is marked as optional")
 }
-func TestStringsAndInts(t *testing.T) {
+
+func TestIgnoreUnknownOptions(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST,optional,other-lib"`
+	}
 	type Config struct {
-		VarA string `env:"VAR_A"`
-		VarB string `env:"VAR_B"`
-		VarC int    `env:"VAR_C"`
-		VarD uint   `env:"VAR_D"`
-		VarE int16  `env:"VAR_E"`
+		VarA     string `env:"VAR_A,other-lib,optional,other=value"`
+		Database `env:"DB_,prefix,other-lib"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
-		"VAR_C": "10",
-		"VAR_D": "20",
-		"VAR_E": "30",
+		"VAR_A":   "VAL_A",
+		"DB_HOST": "localhost",
 	})
+	p.IgnoreUnknownOptions = true
 
 	config := Config{}
 	err := p.Get(&config)
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
+	require.NoError(err, "Get should not fail because unknown options are ignored")
 	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(int(10), config.VarC, "VarC should parse correctly")
-	require.Equal(uint(20), config.VarD, "VarD should parse correctly")
-	require.Equal(int16(30), config.VarE, "VarE should parse correctly")
+	require.Equal("localhost", config.Database.Host, "the prefix should still apply")
 }
 
-func TestNestedStruct(t *testing.T) {
+func TestIgnoreUnknownOptionsStrictByDefault(t *testing.T) {
 	type Config struct {
-		VarA   string `env:"VAR_A"`
-		VarB   string `env:"VAR_B"`
-		Nested struct {
-			VarC int   `env:"VAR_C"`
-			VarD uint  `env:"VAR_D"`
-			VarE int16 `env:"VAR_E"`
-		}
+		VarA string `env:"VAR_A,other-lib,optional"`
 	}
 
 	p := mapToParser(map[string]string{
 		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
-		"VAR_C": "10",
-		"VAR_D": "20",
-		"VAR_E": "30",
 	})
 
 	config := Config{}
 	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,other-lib,optional", "other-lib")
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
-	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(int(10), config.Nested.VarC, "VarC should parse correctly")
-	require.Equal(uint(20), config.Nested.VarD, "VarD should parse correctly")
-	require.Equal(int16(30), config.Nested.VarE, "VarE should parse correctly")
+	require.Equal(expected, err, "Get should fail because of the unknown option")
 }
 
-func TestNestedStructPointer(t *testing.T) {
+func TestIgnoreUnknownOptionsInvalidKnownOption(t *testing.T) {
 	type Config struct {
-		VarA   string `env:"VAR_A"`
-		VarB   string `env:"VAR_B"`
-		Nested *struct {
-			VarC int   `env:"VAR_C"`
-			VarD uint  `env:"VAR_D"`
-			VarE int16 `env:"VAR_E"`
-		}
+		VarA int `env:"VAR_A,other-lib,min=abc"`
+	}
+
+	p := mapToParser(nil)
+	p.IgnoreUnknownOptions = true
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,other-lib,min=abc", "min=abc")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should still fail because of the invalid known option")
+}
+
+func TestIgnoreUnknownOptionsChangedAfterGet(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,other-lib"`
 	}
 
 	p := mapToParser(map[string]string{
 		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
-		"VAR_C": "10",
-		"VAR_D": "20",
-		"VAR_E": "30",
 	})
+	p.IgnoreUnknownOptions = true
 
 	config := Config{}
 	err := p.Get(&config)
 
 	require := require.New(t)
-	require.NoError(err, "Get should not fail")
+	require.NoError(err, "Get should not fail because unknown options are ignored")
 	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(int(10), config.Nested.VarC, "VarC should parse correctly")
-	require.Equal(uint(20), config.Nested.VarD, "VarD should parse correctly")
-	require.Equal(int16(30), config.Nested.VarE, "VarE should parse correctly")
+
+	p.IgnoreUnknownOptions = false
+	err = p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,other-lib", "other-lib")
+	require.Equal(expected, err, "Get should use the new setting even though the tags are cached")
 }
-func TestNestedStructError(t *testing.T) {
+
+func TestByteSlice(t *testing.T) {
 	type Config struct {
-		VarA   string `env:"VAR_A"`
-		VarB   string `env:"VAR_B"`
-		Nested struct {
-			VarC int `env:"VAR_C"`
-		}
+		VarA []byte `env:"VAR_A"`
 	}
 
 	p := mapToParser(map[string]string{
 		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
-		"VAR_C": "not-an-int",
 	})
 
+	expected := []byte{'V', 'A', 'L', '_', 'A'}
 	config := Config{}
 	err := p.Get(&config)
 
 	require := require.New(t)
-	require.Error(err, "Get should fail")
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.VarA, "VarA should parse correctly")
 }
 
-func TestArrayAsJSON(t *testing.T) {
+func TestByteSliceAsJSON(t *testing.T) {
 	type Config struct {
-		VarA  string `env:"VAR_A"`
-		VarB  string `env:"VAR_B"`
-		Array []int  `env:"ARRAY,json"`
+		VarA []byte `env:"VAR_A,json"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
-		"ARRAY": "[9,1,5]",
+		"VAR_A": "[1,2,3]",
 	})
 
+	expected := []byte{1, 2, 3}
 	config := Config{}
 	err := p.Get(&config)
-	expected := []int{9, 1, 5}
+
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
-	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(expected, config.Array, "Array should parse correctly")
+	require.Equal(expected, config.VarA, "VarA should parse as a JSON array")
 }
 
-func TestArrayPointerAsJSON(t *testing.T) {
+func TestInt8SliceAsJSON(t *testing.T) {
 	type Config struct {
-		VarA  string `env:"VAR_A"`
-		VarB  string `env:"VAR_B"`
-		Array []*int `env:"ARRAY,json"`
+		VarA []int8 `env:"VAR_A,json"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A": "VAL_A",
-		"VAR_B": "VAL_B",
-		"ARRAY": "[9,1,5]",
+		"VAR_A": "[-1,0,1]",
 	})
 
+	expected := []int8{-1, 0, 1}
 	config := Config{}
 	err := p.Get(&config)
-	a, b, c := 9, 1, 5
-	expected := []*int{&a, &b, &c}
+
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
-	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(expected, config.Array, "Array should parse correctly")
+	require.Equal(expected, config.VarA, "VarA should parse as a JSON array")
 }
 
-func TestNestedStructPointerAsJSON(t *testing.T) {
-	type Nested struct {
-		VarC int    `json:"varc"`
-		VarD string `json:"vard"`
-	}
+func TestUint8SliceWithoutJSON(t *testing.T) {
 	type Config struct {
-		VarA   string  `env:"VAR_A"`
-		VarB   string  `env:"VAR_B"`
-		Nested *Nested `env:"NESTED,json"`
+		VarA []uint8 `env:"VAR_A"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A":  "VAL_A",
-		"VAR_B":  "VAL_B",
-		"NESTED": `{"varc": 10, "vard": "val_d"}`,
+		"VAR_A": "[1,2,3]",
 	})
 
+	expected := []uint8("[1,2,3]")
 	config := Config{}
 	err := p.Get(&config)
-	expected := &Nested{VarC: 10, VarD: "val_d"}
+
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
-	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(expected, config.Nested, "Nested should parse correctly")
+	require.Equal(expected, config.VarA, "VarA should be the raw bytes")
 }
 
-func TestNestedStructAsJSON(t *testing.T) {
-	type Nested struct {
-		VarC int    `json:"varc"`
-		VarD string `json:"vard"`
-	}
+func TestBase64String(t *testing.T) {
 	type Config struct {
-		VarA   string `env:"VAR_A"`
-		VarB   string `env:"VAR_B"`
-		Nested `env:"NESTED,json"`
+		VarA string `env:"VAR_A,base64"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A":  "VAL_A",
-		"VAR_B":  "VAL_B",
-		"NESTED": `{"varc": 10, "vard": "val_d"}`,
+		"VAR_A": "VkFMX0E=",
 	})
 
 	config := Config{}
 	err := p.Get(&config)
-	expected := Nested{VarC: 10, VarD: "val_d"}
+
 	require := require.New(t)
 	require.NoError(err, "Get should not fail")
 	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
-	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
-	require.Equal(expected, config.Nested, "Nested should parse correctly")
 }
-func TestNestedStructWithConfigTags(t *testing.T) {
-	type Nested struct {
-		VarC int `json:"varc" env:"VAR_C"`
-	}
+
+func TestBase64ByteSlice(t *testing.T) {
 	type Config struct {
-		Nested `env:"NESTED,json"`
+		VarA []byte `env:"VAR_A,base64"`
 	}
 
 	p := mapToParser(map[string]string{
-		"NESTED": "{}",
+		"VAR_A": "VkFMX0E=",
 	})
 
+	expected := []byte{'V', 'A', 'L', '_', 'A'}
 	config := Config{}
 	err := p.Get(&config)
-	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+
 	require := require.New(t)
-	require.Equal(expected, err, "Get should fail because the struct is tagged and has tagged members")
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.VarA, "VarA should parse correctly")
 }
-func TestNestedStructAsInvalidJSON(t *testing.T) {
-	type Nested struct {
-		VarC int    `json:"varc"`
-		VarD string `json:"vard"`
+
+func TestBase64Int(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,base64"`
 	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "MDkxNQ==",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(915, config.VarA, "VarA should parse correctly")
+}
+
+func TestBase64Invalid(t *testing.T) {
 	type Config struct {
-		VarA   string `env:"VAR_A"`
-		VarB   string `env:"VAR_B"`
-		Nested `env:"NESTED,json"`
+		VarA string `env:"VAR_A,base64"`
 	}
 
 	p := mapToParser(map[string]string{
-		"VAR_A":  "VAL_A",
-		"VAR_B":  "VAL_B",
-		"NESTED": "i-am-not-json",
+		"VAR_A": "i-am-not-base64",
 	})
 
 	config := Config{}
 	err := p.Get(&config)
 	// Note that we do not actually expect a nil error.
 	// We care (and test below) that an error is present, but not the error itself.
-	expected := libconfig.NewErrDecodeFailure(nil, "NESTED", "i-am-not-json", "json")
+	expected := libconfig.NewErrDecodeFailure(nil, "VAR_A", "i-am-not-base64", "base64")
 
 	require := require.New(t)
-	require.Error(err, "Get should fail to parse the value as JSON")
+	require.Error(err, "Get should fail to parse the value as the base64")
 	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
 	require.True(ok, "the error should be ErrDecodeFailure")
 	require.Error(specificErr.Because, "Because should be set")
@@ -806,12 +873,4281 @@ func TestNestedStructAsInvalidJSON(t *testing.T) {
 	require.Equal(expected, err, "Get should fail to parse the value as the kind")
 }
 
-func mapToParser(envs map[string]string) libconfig.Parser {
-	return libconfig.Parser{
-		Tag: "env",
-		LookupFn: func(name string) (string, bool) {
-			value, found := envs[name]
-			return value, found
-		},
+func TestLenientBase64(t *testing.T) {
+	type Config struct {
+		Padded   string   `env:"PADDED,base64"`
+		Unpadded string   `env:"UNPADDED,base64"`
+		URL      []byte   `env:"URL,base64"`
+		List     [][]byte `env:"LIST,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PADDED":   "aGk=",
+		"UNPADDED": "aGk",
+		"URL":      "-_8",
+		"LIST":     "aGk=,aGk",
+	})
+	p.LenientBase64 = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("hi", config.Padded, "Padded should be decoded as usual")
+	require.Equal("hi", config.Unpadded, "Unpadded should be decoded without padding")
+	require.Equal([]byte{0xfb, 0xff}, config.URL, "URL should be decoded as URL-safe base64")
+	require.Equal([][]byte{[]byte("hi"), []byte("hi")}, config.List, "each item of List should be decoded leniently")
+}
+
+func TestLenientBase64Garbage(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "!!not base64!!",
+	})
+	p.LenientBase64 = true
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("VAR_A", specificErr.Key, "the error should be for VAR_A")
+	require.IsType(base64.CorruptInputError(0), specificErr.Because, "the error should be from standard base64")
+}
+
+func TestLenientBase64Disabled(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "aGk",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrDecodeFailure{}, err, "unpadded base64 should fail by default")
+}
+
+func TestTwoStrings(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B"`
 	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+}
+
+func TestInt(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(500, config.VarA, "VarA should parse correctly")
+}
+
+func TestIntPointer(t *testing.T) {
+	type Config struct {
+		VarA *int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := 500
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(&expected, config.VarA, "VarA should parse correctly")
+}
+
+func TestStringPointer(t *testing.T) {
+	type Config struct {
+		VarA *string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := "500"
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(&expected, config.VarA, "VarA should parse correctly")
+}
+
+func TestIntPointerToPointer(t *testing.T) {
+	type Config struct {
+		VarA **int  `env:"VAR_A"`
+		VarB ***int `env:"VAR_B,min=1"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+		"VAR_B": "7",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.NotNil(config.VarA, "VarA should be allocated")
+	require.NotNil(*config.VarA, "*VarA should be allocated")
+	require.Equal(500, **config.VarA, "VarA should parse correctly")
+	require.Equal(7, ***config.VarB, "VarB should parse correctly")
+}
+
+func TestBytesPointer(t *testing.T) {
+	type Config struct {
+		VarA *[]byte `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "raw",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := []byte("raw")
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(&expected, config.VarA, "VarA should contain the raw bytes")
+}
+
+func TestLowerUpper(t *testing.T) {
+	type Config struct {
+		Region  string  `env:"REGION,lower"`
+		Code    *string `env:"CODE,upper,oneof=US|EU"`
+		Default string  `env:"DEFAULT,optional,lower,default=Mixed"`
+	}
+
+	p := mapToParser(map[string]string{
+		"REGION": "US-East",
+		"CODE":   "eu",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	code := "EU"
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("us-east", config.Region, "Region should be lowercased")
+	require.Equal(&code, config.Code, "Code should be uppercased before it is validated")
+	require.Equal("mixed", config.Default, "the default should be lowercased")
+}
+
+func TestLowerUpperConflict(t *testing.T) {
+	type Config struct {
+		Region string `env:"REGION,lower,upper"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrConflictingOptions("REGION,lower,upper", "lower", "upper")
+
+	require := require.New(t)
+	require.Equal(expected, err, "lower and upper should not be combined")
+}
+
+func TestLowerNotString(t *testing.T) {
+	type Config struct {
+		Count int `env:"COUNT,lower"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("COUNT,lower", "lower")
+
+	require := require.New(t)
+	require.Equal(expected, err, "lower should only apply to strings")
+}
+
+func TestIntOverflow(t *testing.T) {
+	type Config struct {
+		VarA int8 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	expected := libconfig.NewErrOverflow(reflect.Int8, "VAR_A", "500")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse \"500\" as int8")
+}
+
+func TestIntPlatformWidth(t *testing.T) {
+	type Config struct {
+		Max  int  `env:"MAX"`
+		UMax uint `env:"UMAX"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX":  strconv.Itoa(math.MaxInt),
+		"UMAX": strconv.FormatUint(math.MaxUint, 10),
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should accept the largest values for the platform")
+	require.Equal(math.MaxInt, config.Max, "Max should be the largest int")
+	require.Equal(uint(math.MaxUint), config.UMax, "UMax should be the largest uint")
+}
+
+func TestInt32Boundary(t *testing.T) {
+	type Config struct {
+		Max  int32  `env:"MAX"`
+		Min  int32  `env:"MIN"`
+		UMax uint32 `env:"UMAX"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX":  "2147483647",
+		"MIN":  "-2147483648",
+		"UMAX": "4294967295",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should accept the 32-bit boundaries")
+	require.Equal(int32(math.MaxInt32), config.Max, "Max should be the largest int32")
+	require.Equal(int32(math.MinInt32), config.Min, "Min should be the smallest int32")
+	require.Equal(uint32(math.MaxUint32), config.UMax, "UMax should be the largest uint32")
+}
+
+func TestInt32Overflow(t *testing.T) {
+	type Config struct {
+		VarA int32 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "2147483648",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Int32, "VAR_A", "2147483648")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail one past the largest int32")
+}
+
+func TestUint32Overflow(t *testing.T) {
+	type Config struct {
+		VarA uint32 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "4294967296",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Uint32, "VAR_A", "4294967296")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail one past the largest uint32")
+}
+
+func TestIntCannotParseEnv(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "not-an-int",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	// Note that we do not actually expect a nil error.
+	// We care (and test below) that an error is present, but not the error itself.
+	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Int, "VAR_A", "not-an-int")
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as the kind")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Error(specificErr.Because, "Because should be set")
+	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
+	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+}
+
+func TestUint(t *testing.T) {
+	type Config struct {
+		VarA uint `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(uint(500), config.VarA, "VarA should parse correctly")
+}
+
+func TestUintOverflow(t *testing.T) {
+	type Config struct {
+		VarA uint8 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	expected := libconfig.NewErrOverflow(reflect.Uint8, "VAR_A", "500")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse \"500\" as uint8")
+}
+
+func TestUintCannotParseEnv(t *testing.T) {
+	type Config struct {
+		VarA uint `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "not-a-uint",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	// Note that we do not actually expect a nil error.
+	// We care (and test below) that an error is present, but not the error itself.
+	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Uint, "VAR_A", "not-a-uint")
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as the kind")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Error(specificErr.Because, "Because should be set")
+	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
+	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+}
+
+func TestUintNegative(t *testing.T) {
+	type Config struct {
+		VarA uint16 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "-5",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrCannotParseEnv(libconfig.ErrNegativeUnsigned, reflect.Uint16, "VAR_A", "-5")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because uint16 cannot be negative")
+	require.Equal("cannot parse env [VAR_A] with value [-5] to kind [uint16]: negative values are not allowed for unsigned types", err.Error(), "the error should explain the problem")
+}
+
+func TestCoerceFloatToInt(t *testing.T) {
+	type Config struct {
+		VarA int    `env:"VAR_A"`
+		VarB uint8  `env:"VAR_B"`
+		VarC int64  `env:"VAR_C"`
+		VarD *int32 `env:"VAR_D"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "10.0",
+		"VAR_B": "2e2",
+		"VAR_C": "9007199254740993",
+		"VAR_D": "-3.00",
+	})
+	p.CoerceFloatToInt = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(10, config.VarA, "VarA should be coerced")
+	require.Equal(uint8(200), config.VarB, "VarB should be coerced")
+	require.Equal(int64(9007199254740993), config.VarC, "VarC should not lose precision")
+	require.Equal(int32(-3), *config.VarD, "VarD should be coerced")
+}
+
+func TestCoerceFloatToIntFractional(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "10.5",
+	})
+	p.CoerceFloatToInt = true
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrCannotParseEnv(libconfig.ErrFractional, reflect.Int, "VAR_A", "10.5")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because of the fractional part")
+}
+
+func TestCoerceFloatToIntDisabled(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "10.0",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "Get should fail because floats are not coerced by default")
+}
+
+func TestCoerceFloatToIntChangedAfterGet(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "10.0",
+	})
+	p.CoerceFloatToInt = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(10, config.VarA, "VarA should be coerced")
+
+	p.CoerceFloatToInt = false
+	err = p.Get(&Config{})
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "Get should use the new setting even though the tags are cached")
+}
+
+func TestBasePrefixed(t *testing.T) {
+	type Config struct {
+		Hex    int    `env:"HEX,base=0"`
+		Octal  uint   `env:"OCTAL,base=0"`
+		Legacy int    `env:"LEGACY,base=0"`
+		Binary int8   `env:"BINARY,base=0"`
+		Plain  uint16 `env:"PLAIN,base=0"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HEX":    "0x1F",
+		"OCTAL":  "0o755",
+		"LEGACY": "010",
+		"BINARY": "-0b101",
+		"PLAIN":  "8080",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(31, config.Hex, "Hex should be parsed as hexadecimal")
+	require.Equal(uint(0755), config.Octal, "Octal should be parsed as octal")
+	require.Equal(8, config.Legacy, "Legacy should be parsed as octal")
+	require.Equal(int8(-5), config.Binary, "Binary should be parsed as binary")
+	require.Equal(uint16(8080), config.Plain, "Plain should be parsed as decimal")
+}
+
+func TestBase16(t *testing.T) {
+	type Config struct {
+		Mask uint32 `env:"MASK,base=16"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MASK": "ffff00",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(uint32(0xffff00), config.Mask, "Mask should be parsed as hexadecimal")
+}
+
+func TestBaseDefaultIsDecimal(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+		VarB int `env:"VAR_B"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "010",
+		"VAR_B": "0x1F",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "Get should not accept a prefix without base")
+	require.Equal(10, config.VarA, "VarA should be parsed as decimal")
+}
+
+func TestBaseInvalid(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,base=37"`
+	}
+
+	p := mapToParser(nil)
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,base=37", "base=37")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because of the invalid base")
+}
+
+func TestBaseInvalidType(t *testing.T) {
+	type Config struct {
+		VarA float64 `env:"VAR_A,base=16"`
+	}
+
+	p := mapToParser(nil)
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,base=16", "base=16")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because base requires an integer")
+}
+
+func TestBasePrefixedUnderscores(t *testing.T) {
+	type Config struct {
+		Max int `env:"MAX,base=0"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX": "1_000_000",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(1000000, config.Max, "Max should accept Go-style underscores")
+}
+
+func TestThousands(t *testing.T) {
+	type Config struct {
+		Max     int     `env:"MAX,thousands=comma"`
+		Limit   uint64  `env:"LIMIT,thousands=_"`
+		Ratio   float64 `env:"RATIO,thousands=comma"`
+		Euros   float32 `env:"EUROS,thousands=."`
+		Plain   int     `env:"PLAIN,thousands=comma"`
+		Pointer *int16  `env:"POINTER,thousands='"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX":     "1,000,000",
+		"LIMIT":   "18_000_000_000",
+		"RATIO":   "1,234.5",
+		"EUROS":   "1.234",
+		"PLAIN":   "42",
+		"POINTER": "-12'345",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := int16(-12345)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(1000000, config.Max, "Max should ignore commas")
+	require.Equal(uint64(18000000000), config.Limit, "Limit should ignore underscores")
+	require.Equal(1234.5, config.Ratio, "Ratio should ignore commas but keep the decimal point")
+	require.Equal(float32(1234), config.Euros, "Euros should ignore dots")
+	require.Equal(42, config.Plain, "Plain should parse without separators")
+	require.Equal(&expected, config.Pointer, "Pointer should ignore apostrophes")
+}
+
+func TestThousandsInvalid(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,thousands=ab"`
+	}
+
+	p := mapToParser(nil)
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,thousands=ab", "thousands=ab")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the separator must be a single character")
+}
+
+func TestThousandsInvalidType(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,thousands=comma"`
+	}
+
+	p := mapToParser(nil)
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,thousands=comma", "thousands=comma")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because thousands requires a number")
+}
+
+func TestThousandsWithChar(t *testing.T) {
+	type Config struct {
+		VarA rune `env:"VAR_A,char,thousands=_"`
+	}
+
+	p := mapToParser(nil)
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrConflictingOptions("VAR_A,char,thousands=_", "char", "thousands")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because a character has no thousands")
+}
+
+func TestWrap(t *testing.T) {
+	type Config struct {
+		Byte uint8 `env:"BYTE,wrap"`
+		Int8 int8  `env:"INT8,wrap"`
+		Hex  uint8 `env:"HEX,wrap,base=16"`
+		Fits int16 `env:"FITS,wrap"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BYTE": "300",
+		"INT8": "200",
+		"HEX":  "1ff",
+		"FITS": "-300",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(uint8(44), config.Byte, "Byte should wrap around rather than overflow")
+	require.Equal(int8(-56), config.Int8, "Int8 should wrap around to a negative value")
+	require.Equal(uint8(0xff), config.Hex, "Hex should wrap around after parsing in its base")
+	require.Equal(int16(-300), config.Fits, "Fits should be unchanged because it fits")
+}
+
+func TestWrapInvalidType(t *testing.T) {
+	type Config struct {
+		VarA float64 `env:"VAR_A,wrap"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,wrap", "wrap")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because only integers wrap around")
+}
+
+func TestMessage(t *testing.T) {
+	type Config struct {
+		Password string `env:"DB_PASSWORD,msg=set via vault path secret/db, or ask #ops"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrWithMessage("DB_PASSWORD", "set via vault path secret/db, or ask #ops", libconfig.NewErrVarNotFound("DB_PASSWORD"))
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail with the message")
+	require.Contains(err.Error(), "set via vault path secret/db, or ask #ops", "the message should appear in the error")
+	require.True(errors.Is(err, libconfig.ErrNotFound), "the error should still be ErrNotFound")
+}
+
+func TestMessageParseFailure(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT,optional,msg=must be a port number"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PORT": "http",
+	})
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because PORT is not a number")
+	require.Equal("must be a port number", err.(*libconfig.ErrWithMessage).Message, "the message should be attached")
+
+	var parseErr *libconfig.ErrCannotParseEnv
+	require.True(errors.As(err, &parseErr), "the error should wrap ErrCannotParseEnv")
+	require.Equal("PORT", parseErr.Key, "the error should be for PORT")
+}
+
+func TestMessageNotUsedOnSuccess(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT,msg=must be a port number"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PORT": "8080",
+	})
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(8080, config.Port, "Port should parse correctly")
+}
+
+func TestMessageFollowedByOption(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT,msg=must be a port number,default=8080"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("PORT,msg=must be a port number,default=8080", "msg=must be a port number")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail rather than take default=8080 as part of the message")
+}
+
+func TestMessageFollowedByFlag(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT,msg=must be a port number,optional"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("PORT,msg=must be a port number,optional", "msg=must be a port number")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail rather than take optional as part of the message")
+}
+
+func TestMessageWithEquals(t *testing.T) {
+	type Config struct {
+		Mode string `env:"MODE,msg=set mode=fast, or mode=safe"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because MODE is not set")
+	require.Equal("set mode=fast, or mode=safe", err.(*libconfig.ErrWithMessage).Message, "mode= is not an option, so it is part of the message")
+}
+
+func TestPresence(t *testing.T) {
+	type Config struct {
+		Empty   bool  `env:"EMPTY,presence"`
+		Value   bool  `env:"VALUE,presence"`
+		False   bool  `env:"FALSE,presence"`
+		Unset   bool  `env:"UNSET,presence"`
+		Pointer *bool `env:"POINTER,presence"`
+		Nil     *bool `env:"NIL,presence"`
+	}
+
+	p := mapToParser(map[string]string{
+		"EMPTY":   "",
+		"VALUE":   "x",
+		"FALSE":   "false",
+		"POINTER": "",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.True(config.Empty, "Empty should be true because it is set, even though it is empty")
+	require.True(config.Value, "Value should be true because it is set")
+	require.True(config.False, "False should be true because it is set, whatever its value")
+	require.False(config.Unset, "Unset should be false because it is not set")
+	require.NotNil(config.Pointer, "Pointer should be allocated because it is set")
+	require.True(*config.Pointer, "Pointer should be true because it is set")
+	require.Nil(config.Nil, "Nil should stay nil because it is not set")
+}
+
+func TestPresenceInvalidType(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,presence"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,presence", "presence")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because only bools can be flags")
+}
+
+func TestFloat32(t *testing.T) {
+	type Config struct {
+		VarA float32 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500.5",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(float32(500.5), config.VarA, "VarA should parse correctly")
+}
+
+func TestFloat64(t *testing.T) {
+	type Config struct {
+		VarA float64 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "500.5",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(float64(500.5), config.VarA, "VarA should parse correctly")
+}
+
+func TestFloatOverflow(t *testing.T) {
+	type Config struct {
+		VarA float32 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "1003006009012015018021024027030033036039",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	expected := libconfig.NewErrOverflow(reflect.Float32, "VAR_A", "1003006009012015018021024027030033036039")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse \"1003006009012015018021024027030033036039\" as float32")
+}
+
+func TestFloatCannotParseEnv(t *testing.T) {
+	type Config struct {
+		VarA float64 `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "not-a-float",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	// Note that we do not actually expect a nil error.
+	// We care (and test below) that an error is present, but not the error itself.
+	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Float64, "VAR_A", "not-a-float")
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as the kind")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Error(specificErr.Because, "Because should be set")
+	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
+	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+}
+
+func TestBoolTrue(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "true",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(true, config.VarA, "VarA should parse correctly")
+}
+
+func TestBoolFalse(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "false",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(false, config.VarA, "VarA should parse correctly")
+}
+
+func TestBoolCannotParseEnv(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "not-a-bool",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	// Note that we do not actually expect a nil error.
+	// We care (and test below) that an error is present, but not the error itself.
+	expected := libconfig.NewErrCannotParseEnv(nil, reflect.Bool, "VAR_A", "not-a-bool")
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as the kind")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Error(specificErr.Because, "Because should be set")
+	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
+	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+}
+
+func TestExtendedBools(t *testing.T) {
+	type Config struct {
+		Yes      bool `env:"YES"`
+		No       bool `env:"NO"`
+		On       bool `env:"ON"`
+		Off      bool `env:"OFF"`
+		Enabled  bool `env:"ENABLED"`
+		Disabled bool `env:"DISABLED"`
+		Strict   bool `env:"STRICT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"YES":      "yes",
+		"NO":       "No",
+		"ON":       "ON",
+		"OFF":      "off",
+		"ENABLED":  "Enabled",
+		"DISABLED": "disabled",
+		"STRICT":   "true",
+	})
+	p.ExtendedBools = true
+
+	config := Config{No: true, Off: true, Disabled: true}
+	err := p.Get(&config)
+	expected := Config{Yes: true, On: true, Enabled: true, Strict: true}
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config, "the extended values should be parsed in any case")
+}
+
+func TestExtendedBoolsCannotParseEnv(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "maybe",
+	})
+	p.ExtendedBools = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("maybe", specificErr.Value, "the error should include the value")
+}
+
+func TestExtendedBoolsDisabledByDefault(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "yes",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	_, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "yes should not be a bool unless ExtendedBools is set")
+}
+
+func TestExtendedBoolsChangedAfterGet(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "yes",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "yes should not be a bool before ExtendedBools is set")
+
+	p.ExtendedBools = true
+	config := Config{}
+	err = p.Get(&config)
+	require.NoError(err, "Get should use the new setting even though the tags are cached")
+	require.True(config.VarA, "VarA should be parsed as an extended bool")
+
+	config = Config{}
+	err = p.With("OTHER", "").Get(&config)
+	require.NoError(err, "a copy that shares the cache should use its own setting")
+	require.True(config.VarA, "VarA should be parsed as an extended bool")
+}
+
+func TestIntBool(t *testing.T) {
+	type Config struct {
+		Two      bool  `env:"TWO,intbool"`
+		Zero     bool  `env:"ZERO,intbool"`
+		Negative *bool `env:"NEGATIVE,intbool"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TWO":      "2",
+		"ZERO":     "0",
+		"NEGATIVE": "-1",
+	})
+
+	config := Config{Zero: true}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.True(config.Two, "2 should be true")
+	require.False(config.Zero, "0 should be false")
+	require.True(*config.Negative, "-1 should be true")
+}
+
+func TestIntBoolCannotParseEnv(t *testing.T) {
+	type Config struct {
+		VarA bool `env:"VAR_A,intbool"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "x",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("x", specificErr.Value, "the error should include the value")
+}
+
+func TestIntBoolInvalidType(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,intbool"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,intbool", "intbool")
+
+	require.Equal(t, expected, err, "intbool requires a bool")
+}
+func TestErrCannotSetKindForInterface(t *testing.T) {
+	type Config struct {
+		VarA interface{} `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "false",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrCannotSetKind(reflect.Interface)
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse reflect.Interface")
+}
+
+// celsius is a temperature written like "21.5C"
+type celsius float64
+
+func (c *celsius) UnmarshalText(text []byte) error {
+	if !strings.HasSuffix(string(text), "C") {
+		return fmt.Errorf("temperature [%s] must end in C", text)
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSuffix(string(text), "C"), 64)
+	if err != nil {
+		return err
+	}
+
+	*c = celsius(f)
+	return nil
+}
+
+func TestPresetInterfaceTextUnmarshaler(t *testing.T) {
+	type Config struct {
+		Pointer interface{} `env:"POINTER"`
+		Value   interface{} `env:"VALUE"`
+	}
+
+	p := mapToParser(map[string]string{
+		"POINTER": "21.5C",
+		"VALUE":   "-4C",
+	})
+
+	target := celsius(0)
+	config := Config{Pointer: &target, Value: celsius(0)}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(celsius(21.5), target, "the preset pointer should be filled in place")
+	require.Equal(celsius(-4), config.Value, "the preset value should be replaced")
+}
+
+func TestPresetInterfaceTextUnmarshalerFailure(t *testing.T) {
+	type Config struct {
+		Temp interface{} `env:"TEMP"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TEMP": "21.5F",
+	})
+
+	config := Config{Temp: new(celsius)}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because TEMP is not in Celsius")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("text", specificErr.Type, "the error should be from UnmarshalText")
+}
+
+func TestPresetInterfaceWithoutTextUnmarshaler(t *testing.T) {
+	type Config struct {
+		VarA interface{} `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "1",
+	})
+
+	config := Config{VarA: 0}
+	err := p.Get(&config)
+	expected := libconfig.NewErrCannotSetKind(reflect.Interface)
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because an int has no UnmarshalText")
+}
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) UnmarshalJSON(data []byte) error {
+	var coords []int
+	err := json.Unmarshal(data, &coords)
+	if err != nil {
+		return err
+	}
+	if len(coords) != 2 {
+		return fmt.Errorf("expected 2 coordinates but got %d", len(coords))
+	}
+
+	p.X, p.Y = coords[0], coords[1]
+	return nil
+}
+
+type labels map[string]string
+
+func (l *labels) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*map[string]string)(l))
+}
+
+func TestJSONUnmarshalerWithoutTag(t *testing.T) {
+	type Config struct {
+		Point   point           `env:"POINT"`
+		Pointer *point          `env:"POINTER"`
+		Labels  labels          `env:"LABELS"`
+		Raw     json.RawMessage `env:"RAW"`
+	}
+
+	p := mapToParser(map[string]string{
+		"POINT":   "[1,2]",
+		"POINTER": "[3,4]",
+		"LABELS":  `{"a": "b"}`,
+		"RAW":     `{"c": 1}`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(point{1, 2}, config.Point, "Point should be decoded with UnmarshalJSON")
+	require.Equal(&point{3, 4}, config.Pointer, "Pointer should be decoded with UnmarshalJSON")
+	require.Equal(labels{"a": "b"}, config.Labels, "Labels should be decoded with UnmarshalJSON")
+	require.Equal(json.RawMessage(`{"c": 1}`), config.Raw, "Raw should contain the raw bytes")
+}
+
+func TestJSONUnmarshalerWithoutTagFailure(t *testing.T) {
+	type Config struct {
+		Point point `env:"POINT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"POINT": "[1,2,3]",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to decode the point")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("json", specificErr.Type, "the error should be for json")
+}
+
+func TestJSONRawMessage(t *testing.T) {
+	type Config struct {
+		Raw json.RawMessage `env:"RAW,json"`
+	}
+
+	raw := `{"a": {"b": [1, 2]}, "c": "d"}`
+	p := mapToParser(map[string]string{
+		"RAW": raw,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(json.RawMessage(raw), config.Raw, "Raw should contain the raw JSON")
+}
+
+func TestJSONRawMessageInvalid(t *testing.T) {
+	type Config struct {
+		Raw json.RawMessage `env:"RAW,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"RAW": `{"a": `,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because RAW is not valid JSON")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("json", specificErr.Type, "the error should be for json")
+	require.Equal("RAW", specificErr.Key, "the error should be for RAW")
+}
+
+func TestRegexp(t *testing.T) {
+	type Config struct {
+		Matcher *regexp.Regexp `env:"MATCH"`
+		Encoded *regexp.Regexp `env:"ENCODED,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MATCH":   `^level=(warn|error)$`,
+		"ENCODED": base64.StdEncoding.EncodeToString([]byte(`\d+`)),
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(`^level=(warn|error)$`, config.Matcher.String(), "Matcher should be compiled from MATCH")
+	require.True(config.Matcher.MatchString("level=warn"), "Matcher should match")
+	require.Equal(`\d+`, config.Encoded.String(), "Encoded should be decoded before compiling")
+}
+
+func TestRegexpInvalid(t *testing.T) {
+	type Config struct {
+		Matcher *regexp.Regexp `env:"MATCH"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MATCH": `(unclosed`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because MATCH does not compile")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("MATCH", specificErr.Key, "the error should be for MATCH")
+	require.Equal(`(unclosed`, specificErr.Value, "the error should include the value")
+}
+
+func TestRegexpElements(t *testing.T) {
+	type Filter struct {
+		Name    string
+		Matcher *regexp.Regexp
+	}
+	type Config struct {
+		List   []*regexp.Regexp          `env:"LIST,csv"`
+		Map    map[string]*regexp.Regexp `env:"MAP"`
+		Filter Filter                    `env:"FILTER,poscsv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LIST":   `^a$,^b+$`,
+		"MAP":    `x=^x$`,
+		"FILTER": `errors,^level=error$`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Len(config.List, 2, "List should have two elements")
+	require.Equal(`^b+$`, config.List[1].String(), "each element of List should be compiled")
+	require.Equal(`^x$`, config.Map["x"].String(), "each value of Map should be compiled")
+	require.Equal(`^level=error$`, config.Filter.Matcher.String(), "the field of Filter should be compiled")
+}
+
+func TestHardwareAddr(t *testing.T) {
+	type Config struct {
+		MAC     net.HardwareAddr  `env:"MAC"`
+		Pointer *net.HardwareAddr `env:"POINTER"`
+		Encoded net.HardwareAddr  `env:"ENCODED,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAC":     "01:23:45:67:89:ab",
+		"POINTER": "01-23-45-67-89-AB",
+		"ENCODED": "ASNFZ4mr",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := net.HardwareAddr{0x01, 0x23, 0x45, 0x67, 0x89, 0xab}
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.MAC, "MAC should be parsed as a MAC address")
+	require.Equal(expected, *config.Pointer, "Pointer should be parsed as a MAC address")
+	require.Equal(expected, config.Encoded, "Encoded should be decoded as raw bytes")
+}
+
+func TestHardwareAddrInvalid(t *testing.T) {
+	type Config struct {
+		MAC net.HardwareAddr `env:"MAC"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAC": "01:23:45:67:89",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because MAC is too short")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("MAC", specificErr.Key, "the error should be for MAC")
+	require.Equal("01:23:45:67:89", specificErr.Value, "the error should include the value")
+}
+
+func TestCalendar(t *testing.T) {
+	type Config struct {
+		StartDay time.Weekday  `env:"START_DAY"`
+		EndDay   time.Weekday  `env:"END_DAY"`
+		Month    time.Month    `env:"MONTH"`
+		Number   time.Month    `env:"NUMBER"`
+		Pointer  *time.Weekday `env:"POINTER"`
+	}
+
+	p := mapToParser(map[string]string{
+		"START_DAY": "Monday",
+		"END_DAY":   "3",
+		"MONTH":     "december",
+		"NUMBER":    "2",
+		"POINTER":   "SUNDAY",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(time.Monday, config.StartDay, "StartDay should be parsed by name")
+	require.Equal(time.Wednesday, config.EndDay, "EndDay should be parsed by number")
+	require.Equal(time.December, config.Month, "Month should be parsed by name in any case")
+	require.Equal(time.February, config.Number, "Number should be parsed by number")
+	require.Equal(time.Sunday, *config.Pointer, "Pointer should be parsed by name")
+}
+
+func TestCalendarInvalid(t *testing.T) {
+	type Config struct {
+		Month time.Month `env:"MONTH"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MONTH": "13",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrCannotParseEnv(libconfig.ErrInvalidCalendarValue, reflect.Int, "MONTH", "13")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because there are only twelve months")
+}
+
+func TestCalendarInvalidName(t *testing.T) {
+	type Config struct {
+		Day time.Weekday `env:"DAY"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DAY": "Mon",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrCannotParseEnv(libconfig.ErrInvalidCalendarValue, reflect.Int, "DAY", "Mon")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because abbreviations are not names")
+	require.True(errors.Is(err, libconfig.ErrInvalidCalendarValue), "the cause should be ErrInvalidCalendarValue")
+}
+
+func TestSQLNull(t *testing.T) {
+	type Config struct {
+		Host    sql.NullString  `env:"HOST"`
+		Replica sql.NullString  `env:"REPLICA,optional"`
+		Port    sql.NullInt64   `env:"PORT"`
+		Ratio   sql.NullFloat64 `env:"RATIO,optional"`
+		TLS     *sql.NullBool   `env:"TLS"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOST": "db.local",
+		"PORT": "5432",
+		"TLS":  "true",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(sql.NullString{String: "db.local", Valid: true}, config.Host, "Host should be valid")
+	require.Equal(sql.NullString{}, config.Replica, "Replica should not be valid because it is missing")
+	require.Equal(sql.NullInt64{Int64: 5432, Valid: true}, config.Port, "Port should be valid")
+	require.False(config.Ratio.Valid, "Ratio should not be valid because it is missing")
+	require.Equal(&sql.NullBool{Bool: true, Valid: true}, config.TLS, "TLS should be valid")
+}
+
+func TestSQLNullInvalid(t *testing.T) {
+	type Config struct {
+		Port sql.NullInt64 `env:"PORT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PORT": "abc",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because PORT is not an integer")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("PORT", specificErr.Key, "the error should be for PORT")
+	require.Equal("scan", specificErr.Type, "the error should be from Scan")
+}
+
+func TestCollectErrors(t *testing.T) {
+	type Server struct {
+		Port int `env:"PORT"`
+	}
+	type Config struct {
+		VarA     int    `env:"VAR_A"`
+		VarB     string `env:"VAR_B"`
+		Database struct {
+			Host string `env:"HOST"`
+			Port int    `env:"PORT"`
+		} `env:"DB_,prefix"`
+		Servers []Server `env:"SERVER,indexed"`
+		VarC    bool     `env:"VAR_C"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":         "abc",
+		"VAR_B":         "ok",
+		"DB_PORT":       "xyz",
+		"SERVER_0_PORT": "1",
+		"SERVER_1_PORT": "-",
+	})
+	p.CollectErrors = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail")
+	specificErr, ok := err.(*libconfig.ErrMultiple)
+	require.True(ok, "the error should be ErrMultiple")
+
+	paths := []string{}
+	for _, err := range specificErr.Errors {
+		if fieldErr, ok := err.(*libconfig.ErrField); ok {
+			paths = append(paths, fieldErr.Path)
+		} else {
+			paths = append(paths, err.(interface{ ErrorCode() string }).ErrorCode())
+		}
+	}
+	require.Equal([]string{
+		"cannot_parse_env",
+		"Database.Host",
+		"Database.Port",
+		"Servers[1].Port",
+		"var_not_found",
+	}, paths, "the errors should be in the order of the fields, depth-first")
+	require.Equal("ok", config.VarB, "the fields that did not fail should be set")
+	require.Equal(1, config.Servers[0].Port, "the elements that did not fail should be set")
+
+	var notFound *libconfig.ErrVarNotFound
+	require.True(errors.As(err, &notFound), "errors.As should find the collected errors")
+	require.Equal("DB_HOST", notFound.Key, "errors.As should find the first ErrVarNotFound")
+}
+
+func TestCollectErrorsNone(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{"VAR_A": "1"})
+	p.CollectErrors = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(1, config.VarA, "VarA should be set")
+}
+
+func TestHexByteArray(t *testing.T) {
+	type Config struct {
+		ID      [16]byte `env:"ID,hex"`
+		Pointer *[4]byte `env:"POINTER,hex"`
+		Raw     [3]byte  `env:"RAW"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ID":      "00112233445566778899aabbccddeeff",
+		"POINTER": "DEADBEEF",
+		"RAW":     "abc",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, config.ID, "ID should be decoded from hex")
+	require.Equal(&[4]byte{0xde, 0xad, 0xbe, 0xef}, config.Pointer, "Pointer should be decoded from hex")
+	require.Equal([3]byte{'a', 'b', 'c'}, config.Raw, "Raw should contain the raw bytes")
+}
+
+func TestHexByteArrayWrongLength(t *testing.T) {
+	type Config struct {
+		ID [16]byte `env:"ID,hex"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ID": "00112233445566778899aabbccddee",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrWrongLength("ID", 16, 15)
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because ID is one byte short")
+}
+
+func TestHexInvalid(t *testing.T) {
+	type Config struct {
+		ID [2]byte `env:"ID,hex"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ID": "zzzz",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("hex", specificErr.Type, "the error should be for hex")
+}
+
+func TestHexInvalidOption(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,hex"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,hex", "hex")
+
+	require := require.New(t)
+	require.Equal(expected, err, "hex should only be allowed for byte arrays")
+}
+func TestStringsAndInts(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B"`
+		VarC int    `env:"VAR_C"`
+		VarD uint   `env:"VAR_D"`
+		VarE int16  `env:"VAR_E"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+		"VAR_C": "10",
+		"VAR_D": "20",
+		"VAR_E": "30",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(int(10), config.VarC, "VarC should parse correctly")
+	require.Equal(uint(20), config.VarD, "VarD should parse correctly")
+	require.Equal(int16(30), config.VarE, "VarE should parse correctly")
+}
+
+func TestNestedStruct(t *testing.T) {
+	type Config struct {
+		VarA   string `env:"VAR_A"`
+		VarB   string `env:"VAR_B"`
+		Nested struct {
+			VarC int   `env:"VAR_C"`
+			VarD uint  `env:"VAR_D"`
+			VarE int16 `env:"VAR_E"`
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+		"VAR_C": "10",
+		"VAR_D": "20",
+		"VAR_E": "30",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(int(10), config.Nested.VarC, "VarC should parse correctly")
+	require.Equal(uint(20), config.Nested.VarD, "VarD should parse correctly")
+	require.Equal(int16(30), config.Nested.VarE, "VarE should parse correctly")
+}
+
+func TestNestedStructPointer(t *testing.T) {
+	type Config struct {
+		VarA   string `env:"VAR_A"`
+		VarB   string `env:"VAR_B"`
+		Nested *struct {
+			VarC int   `env:"VAR_C"`
+			VarD uint  `env:"VAR_D"`
+			VarE int16 `env:"VAR_E"`
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+		"VAR_C": "10",
+		"VAR_D": "20",
+		"VAR_E": "30",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(int(10), config.Nested.VarC, "VarC should parse correctly")
+	require.Equal(uint(20), config.Nested.VarD, "VarD should parse correctly")
+	require.Equal(int16(30), config.Nested.VarE, "VarE should parse correctly")
+}
+
+func TestNestedStructPointerAbsent(t *testing.T) {
+	type TLS struct {
+		Cert string `env:"TLS_CERT,optional"`
+		Key  string `env:"TLS_KEY,optional"`
+	}
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		TLS  *TLS
+		Auth *struct {
+			Token *struct {
+				Value string `env:"TOKEN,optional"`
+			}
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Nil(config.TLS, "TLS should stay nil because none of its fields were found")
+	require.Nil(config.Auth, "Auth should stay nil because none of its nested fields were found")
+}
+
+func TestNestedStructPointerPartlyPresent(t *testing.T) {
+	type TLS struct {
+		Cert string `env:"TLS_CERT,optional"`
+		Key  string `env:"TLS_KEY,optional"`
+	}
+	type Config struct {
+		TLS     *TLS
+		Preset  *TLS `env:"PRESET_,prefix"`
+		Default *struct {
+			Port int `env:"PORT,optional,default=8080"`
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"TLS_CERT": "cert.pem",
+	})
+
+	config := Config{Preset: &TLS{}}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(&TLS{Cert: "cert.pem"}, config.TLS, "TLS should be allocated because one of its fields was found")
+	require.Equal(&TLS{}, config.Preset, "a pointer that was already set should be kept")
+	require.Equal(8080, config.Default.Port, "a default should count as resolving the field")
+}
+func TestNestedStructError(t *testing.T) {
+	type Config struct {
+		VarA   string `env:"VAR_A"`
+		VarB   string `env:"VAR_B"`
+		Nested struct {
+			VarC int `env:"VAR_C"`
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+		"VAR_C": "not-an-int",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail")
+}
+
+func TestArrayAsJSON(t *testing.T) {
+	type Config struct {
+		VarA  string `env:"VAR_A"`
+		VarB  string `env:"VAR_B"`
+		Array []int  `env:"ARRAY,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+		"ARRAY": "[9,1,5]",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := []int{9, 1, 5}
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(expected, config.Array, "Array should parse correctly")
+}
+
+func TestArrayPointerAsJSON(t *testing.T) {
+	type Config struct {
+		VarA  string `env:"VAR_A"`
+		VarB  string `env:"VAR_B"`
+		Array []*int `env:"ARRAY,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+		"ARRAY": "[9,1,5]",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	a, b, c := 9, 1, 5
+	expected := []*int{&a, &b, &c}
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(expected, config.Array, "Array should parse correctly")
+}
+
+func TestNestedStructPointerAsJSON(t *testing.T) {
+	type Nested struct {
+		VarC int    `json:"varc"`
+		VarD string `json:"vard"`
+	}
+	type Config struct {
+		VarA   string  `env:"VAR_A"`
+		VarB   string  `env:"VAR_B"`
+		Nested *Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":  "VAL_A",
+		"VAR_B":  "VAL_B",
+		"NESTED": `{"varc": 10, "vard": "val_d"}`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := &Nested{VarC: 10, VarD: "val_d"}
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(expected, config.Nested, "Nested should parse correctly")
+}
+
+func TestNestedStructAsJSON(t *testing.T) {
+	type Nested struct {
+		VarC int    `json:"varc"`
+		VarD string `json:"vard"`
+	}
+	type Config struct {
+		VarA   string `env:"VAR_A"`
+		VarB   string `env:"VAR_B"`
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":  "VAL_A",
+		"VAR_B":  "VAL_B",
+		"NESTED": `{"varc": 10, "vard": "val_d"}`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := Nested{VarC: 10, VarD: "val_d"}
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("VAL_B", config.VarB, "VarB should parse correctly")
+	require.Equal(expected, config.Nested, "Nested should parse correctly")
+}
+func TestNestedStructWithConfigTags(t *testing.T) {
+	type Nested struct {
+		VarC int `json:"varc" env:"VAR_C"`
+	}
+	type Config struct {
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": "{}",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the struct is tagged and has tagged members")
+}
+
+func TestNestedStructWithConfigTagsAllowed(t *testing.T) {
+	type Nested struct {
+		VarC int    `json:"varc" env:"VAR_C"`
+		VarD string `json:"vard" env:"VAR_D"`
+	}
+	type Config struct {
+		Nested  `env:"NESTED,json"`
+		Pointer *Nested `env:"POINTER,json,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": `{"varc": 1}`,
+		"VAR_C":  "2",
+		"VAR_D":  "ignored",
+	})
+	p.AllowNestedTags = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail because nested tags are allowed")
+	require.Equal(Nested{VarC: 1}, config.Nested, "Nested should only be decoded from JSON")
+	require.Nil(config.Pointer, "Pointer should not be allocated")
+
+	plan, err := p.PlanFor(reflect.TypeOf(config))
+	require.NoError(err, "PlanFor should not fail because nested tags are allowed")
+	require.Len(plan.Steps, 2, "the plan should not include the nested fields")
+}
+
+func TestNestedStructAsInvalidJSON(t *testing.T) {
+	type Nested struct {
+		VarC int    `json:"varc"`
+		VarD string `json:"vard"`
+	}
+	type Config struct {
+		VarA   string `env:"VAR_A"`
+		VarB   string `env:"VAR_B"`
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":  "VAL_A",
+		"VAR_B":  "VAL_B",
+		"NESTED": "i-am-not-json",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	// Note that we do not actually expect a nil error.
+	// We care (and test below) that an error is present, but not the error itself.
+	expected := libconfig.NewErrDecodeFailure(nil, "NESTED", "i-am-not-json", "json")
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as JSON")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Error(specificErr.Because, "Because should be set")
+	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
+	require.Equal(expected, err, "Get should fail to parse the value as the kind")
+}
+
+func TestJSONDisallowUnknownFields(t *testing.T) {
+	type Nested struct {
+		VarC int `json:"varc"`
+	}
+	type Config struct {
+		Nested Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": `{"varc": 1, "extra": true}`,
+	})
+
+	require := require.New(t)
+
+	config := Config{}
+	err := p.Get(&config)
+	require.NoError(err, "Get should ignore the unknown field by default")
+	require.Equal(1, config.Nested.VarC, "VarC should parse correctly")
+
+	p.JSONDisallowUnknownFields = true
+	config = Config{}
+	err = p.Get(&config)
+	require.Error(err, "Get should fail because of the unknown field")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("json", specificErr.Type, "the error should be for json")
+	require.Contains(specificErr.Because.Error(), "extra", "the cause should name the unknown field")
+}
+
+func TestJSONDisallowUnknownFieldsTrailingData(t *testing.T) {
+	type Config struct {
+		VarA []int `env:"VAR_A,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": `[1, 2] [3]`,
+	})
+	p.JSONDisallowUnknownFields = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrDecodeFailure{}, err, "Get should fail because of the trailing data")
+}
+
+func TestJSONUnmarshal(t *testing.T) {
+	type Config struct {
+		Limits map[string]int `env:"LIMITS,json"`
+		Plain  string         `env:"PLAIN"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LIMITS": `{"cpu": 1}`,
+		"PLAIN":  "plain",
+	})
+
+	calls := []string{}
+	p.JSONUnmarshal = func(data []byte, v interface{}) error {
+		calls = append(calls, string(data))
+		return json.Unmarshal(data, v)
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]int{"cpu": 1}, config.Limits, "Limits should be decoded by JSONUnmarshal")
+	require.Equal([]string{`{"cpu": 1}`}, calls, "JSONUnmarshal should only be called for fields tagged with json")
+}
+
+func TestJSONUnmarshalFailure(t *testing.T) {
+	type Config struct {
+		Limits map[string]int `env:"LIMITS,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LIMITS": `{"cpu": 1}`,
+	})
+
+	cause := errors.New("custom failure")
+	p.JSONUnmarshal = func(data []byte, v interface{}) error {
+		return cause
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrDecodeFailure(cause, "LIMITS", `{"cpu": 1}`, "json")
+
+	require := require.New(t)
+	require.Equal(expected, err, "the error from JSONUnmarshal should be wrapped")
+}
+
+func TestPreserveOnJSONNull(t *testing.T) {
+	type Limits struct {
+		Max int `json:"max"`
+	}
+	type Config struct {
+		Pointer *Limits           `env:"POINTER,json"`
+		Value   map[string]string `env:"VALUE,json"`
+		Unset   []int             `env:"UNSET,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"POINTER": "null",
+		"VALUE":   "null",
+		"UNSET":   "null",
+	})
+	p.PreserveOnJSONNull = true
+
+	config := Config{
+		Pointer: &Limits{Max: 10},
+		Value:   map[string]string{"team": "core"},
+	}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(&Limits{Max: 10}, config.Pointer, "Pointer should stay as preset")
+	require.Equal(map[string]string{"team": "core"}, config.Value, "Value should stay as preset")
+	require.Nil(config.Unset, "Unset should stay nil")
+}
+
+func TestPreserveOnJSONNullDisabled(t *testing.T) {
+	type Config struct {
+		Value map[string]string `env:"VALUE,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VALUE": "null",
+	})
+
+	config := Config{
+		Value: map[string]string{"team": "core"},
+	}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Nil(config.Value, "json.Unmarshal should zero Value by default")
+}
+
+type Database struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT,optional"`
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	type Config struct {
+		Database
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "5432",
+		"VAR_A": "VAL_A",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(Database{"localhost", 5432}, config.Database, "the embedded fields should belong to the parent")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+}
+
+func TestEmbeddedStructPrefix(t *testing.T) {
+	type Config struct {
+		Database `env:"DB_,prefix"`
+		Replica  *struct {
+			Database `env:"REPLICA_,prefix"`
+		} `env:"DB_,prefix"`
+	}
+
+	p := mapToParser(map[string]string{
+		"APP_DB_HOST":         "primary",
+		"APP_DB_PORT":         "5432",
+		"APP_DB_REPLICA_HOST": "replica",
+	})
+	p.Prefix = "APP_"
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(Database{"primary", 5432}, config.Database, "the embedded fields should use the prefix")
+	require.Equal(Database{"replica", 0}, config.Replica.Database, "nested prefixes should accumulate")
+}
+
+func TestEmbeddedStructPrefixRequiredButMissing(t *testing.T) {
+	type Config struct {
+		Database `env:"DB_,prefix"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOST": "localhost",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrField("Database.Host", libconfig.NewErrVarNotFound("DB_HOST"))
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because DB_HOST is not available")
+}
+
+func TestNestedErrorPath(t *testing.T) {
+	type Primary struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	type Database struct {
+		Primary *Primary `env:"PRIMARY_,prefix"`
+	}
+	type Config struct {
+		Name     string   `env:"NAME"`
+		Database Database `env:"DB_,prefix"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NAME":            "app",
+		"DB_PRIMARY_HOST": "localhost",
+		"DB_PRIMARY_PORT": "not-a-port",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrField)
+	require.True(ok, "the error should be ErrField")
+	require.Equal("Database.Primary.Port", specificErr.Path, "the error should have the path of the field")
+	require.Contains(err.Error(), "Database.Primary.Port", "the message should include the path")
+
+	var parseErr *libconfig.ErrCannotParseEnv
+	require.True(errors.As(err, &parseErr), "the underlying error should be accessible")
+	require.Equal("DB_PRIMARY_PORT", parseErr.Key, "the underlying error should have the name of the variable")
+}
+
+func TestTopLevelErrorHasNoPath(t *testing.T) {
+	type Config struct {
+		Name string `env:"NAME"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrVarNotFound("NAME")
+
+	require := require.New(t)
+	require.Equal(expected, err, "errors for fields of the config should not be wrapped")
+}
+
+func TestEmbeddedStructAsJSONWithConfigTags(t *testing.T) {
+	type Config struct {
+		Database `env:"DB,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB": `{"Host": "localhost"}`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrNestedTags("Database", "DB")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because an embedded json struct cannot have tagged members")
+}
+
+func TestNestedStructAsJSONWithDeepConfigTags(t *testing.T) {
+	type Nested struct {
+		Inner struct {
+			VarC int `env:"VAR_C"`
+		}
+	}
+	type Config struct {
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": "{}",
+		"VAR_C":  "10",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the struct has deeply tagged members")
+}
+
+func TestPrefixWithOtherOption(t *testing.T) {
+	type Config struct {
+		Database `env:"DB_,prefix,optional"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("DB_,prefix,optional", "prefix")
+
+	require := require.New(t)
+	require.Equal(expected, err, "prefix cannot be combined with other options")
+}
+
+func TestPrefixNotStruct(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,prefix"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,prefix", "prefix")
+
+	require := require.New(t)
+	require.Equal(expected, err, "prefix should only apply to structs")
+}
+
+func TestGetWithDefaults(t *testing.T) {
+	type Config struct {
+		VarA string  `env:"VAR_A"`
+		VarB int     `env:"VAR_B,optional"`
+		VarC *string `env:"VAR_C,optional"`
+		VarD uint    `env:"VAR_D,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_D": "20",
+	})
+
+	defaultC := "DEFAULT_C"
+	defaults := Config{
+		VarA: "DEFAULT_A",
+		VarB: 10,
+		VarC: &defaultC,
+		VarD: 1,
+	}
+
+	config := Config{}
+	err := p.GetWithDefaults(&config, defaults)
+
+	require := require.New(t)
+	require.NoError(err, "GetWithDefaults should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal(10, config.VarB, "VarB should retain its default")
+	require.Equal(&defaultC, config.VarC, "VarC should retain its default")
+	require.Equal(uint(20), config.VarD, "VarD should parse correctly")
+	require.Equal("DEFAULT_A", defaults.VarA, "defaults should not be modified")
+}
+
+func TestGetWithDefaultsPointer(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,optional"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{}
+	err := p.GetWithDefaults(&config, &Config{VarA: 10})
+
+	require := require.New(t)
+	require.NoError(err, "GetWithDefaults should not fail")
+	require.Equal(10, config.VarA, "VarA should retain its default")
+}
+
+func TestGetWithDefaultsNotShared(t *testing.T) {
+	type Database struct {
+		Host string `env:"DB_HOST"`
+	}
+	type Config struct {
+		DB     *Database
+		Limits map[string]int `env:"LIMITS,optional"`
+		Hosts  []string       `env:"HOSTS,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DB_HOST": "db.example.com",
+		"LIMITS":  "cpu=2",
+	})
+
+	defaults := Config{
+		DB:     &Database{Host: "localhost"},
+		Limits: map[string]int{"cpu": 1},
+		Hosts:  []string{"a"},
+	}
+
+	config := Config{}
+	err := p.GetWithDefaults(&config, &defaults)
+
+	require := require.New(t)
+	require.NoError(err, "GetWithDefaults should not fail")
+	require.Equal("db.example.com", config.DB.Host, "Host should parse correctly")
+	require.Equal(map[string]int{"cpu": 2}, config.Limits, "Limits should parse correctly")
+	require.Equal("localhost", defaults.DB.Host, "the nested defaults should not be modified")
+	require.Equal(map[string]int{"cpu": 1}, defaults.Limits, "the default map should not be modified")
+
+	config.Hosts[0] = "b"
+	require.Equal([]string{"a"}, defaults.Hosts, "the default slice should not be shared")
+}
+
+func TestGetWithDefaultsTypeMismatch(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,optional"`
+	}
+	type Other struct {
+		VarA int `env:"VAR_A,optional"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{}
+	err := p.GetWithDefaults(&config, Other{VarA: 10})
+	expected := libconfig.NewErrDefaultsTypeMismatch(reflect.TypeOf(config), reflect.TypeOf(Other{}))
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetWithDefaults should fail because the types differ")
+	require.Equal(0, config.VarA, "config should not be modified")
+}
+
+func TestGetWithDefaultsInvalidConfigType(t *testing.T) {
+	p := mapToParser(nil)
+
+	var config int
+	err := p.GetWithDefaults(config, config)
+	expected := libconfig.NewErrInvalidConfigType(reflect.TypeOf(config))
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetWithDefaults should fail with ErrInvalidConfigType")
+}
+
+func TestGetWithDefaultsTypeMismatchNamespace(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,optional"`
+	}
+	type Other struct {
+		VarA int `env:"VAR_A,optional"`
+	}
+
+	var result error
+	p := mapToParser(nil)
+	p.Namespace = "billing"
+	p.OnResult = func(namespace string, err error) {
+		result = err
+	}
+
+	err := p.GetWithDefaults(&Config{}, Other{VarA: 10})
+	expected := libconfig.NewErrNamespace("billing", libconfig.NewErrDefaultsTypeMismatch(reflect.TypeOf(Config{}), reflect.TypeOf(Other{})))
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetWithDefaults should fail with the namespace")
+	require.Equal(expected, result, "OnResult should be called with the returned error")
+}
+
+func TestGetWithDefaultsInvalidConfigTypeNamespace(t *testing.T) {
+	var result error
+	p := mapToParser(nil)
+	p.Namespace = "billing"
+	p.OnResult = func(namespace string, err error) {
+		result = err
+	}
+
+	var config int
+	err := p.GetWithDefaults(config, config)
+	expected := libconfig.NewErrNamespace("billing", libconfig.NewErrInvalidConfigType(reflect.TypeOf(config)))
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetWithDefaults should fail with the namespace")
+	require.Equal(expected, result, "OnResult should be called with the returned error")
+}
+
+func TestErrorOnPreset(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "VAL_B",
+	})
+	p.ErrorOnPreset = true
+
+	config := Config{}
+	err := p.Get(&config)
+	require.NoError(t, err, "the first Get should not fail")
+
+	err = p.Get(&config)
+	expected := libconfig.NewErrAlreadySet("VAR_A")
+	require.Equal(t, expected, err, "the second Get should fail because VAR_A is already set")
+}
+
+func TestErrorOnPresetOptional(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+	p.ErrorOnPreset = true
+
+	config := Config{VarA: "default"}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail because optional fields may hold defaults")
+	require.Equal("VAL_A", config.VarA, "VarA should be overwritten")
+}
+
+func TestErrorOnPresetDisabled(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+
+	config := Config{VarA: "preset"}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail without ErrorOnPreset")
+	require.Equal("VAL_A", config.VarA, "VarA should be overwritten")
+}
+
+func TestPrefix(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"APP_VAR_A": "VAL_A",
+		"VAR_B":     "VAL_B",
+	})
+	p.Prefix = "APP_"
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+	require.Equal("", config.VarB, "VarB should not be found without the prefix")
+}
+
+func TestPrefixRequiredButMissing(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+	p.Prefix = "APP_"
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("APP_VAR_A")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because APP_VAR_A is not available")
+}
+
+func TestOneOf(t *testing.T) {
+	type Config struct {
+		VarA string  `env:"VAR_A,oneof=debug|info|warn|error"`
+		VarB *string `env:"VAR_B,oneof=debug|info"`
+		VarC string  `env:"VAR_C,json,oneof=debug|info"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "warn",
+		"VAR_B": "info",
+		"VAR_C": `"debug"`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := "info"
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("warn", config.VarA, "VarA should parse correctly")
+	require.Equal(&expected, config.VarB, "VarB should parse correctly")
+	require.Equal("debug", config.VarC, "VarC should parse correctly")
+}
+
+func TestOneOfNotInEnum(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,oneof=debug|info|warn|error"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "verbose",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrNotInEnum("VAR_A", "verbose", []string{"debug", "info", "warn", "error"})
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because verbose is not allowed")
+}
+
+func TestOneOfDefault(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional,oneof=debug|info"`
+		VarB string `env:"VAR_B,optional,oneof=debug|info"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{VarA: "info"}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail because the default is valid and VarB is unset")
+	require.Equal("info", config.VarA, "VarA should keep its default")
+
+	config = Config{VarA: "verbose"}
+	err = p.Get(&config)
+	expected := libconfig.NewErrNotInEnum("VAR_A", "verbose", []string{"debug", "info"})
+	require.Equal(expected, err, "Get should fail because the default is not valid")
+}
+
+func TestOneOfNotString(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,oneof=1|2"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,oneof=1|2", "oneof=1|2")
+
+	require := require.New(t)
+	require.Equal(expected, err, "oneof should only apply to strings")
+}
+
+func TestOneOfEmpty(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,oneof="`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,oneof=", "oneof=")
+
+	require := require.New(t)
+	require.Equal(expected, err, "oneof requires at least one value")
+}
+
+func TestOneOfFuzzy(t *testing.T) {
+	type Config struct {
+		LogLevel  string  `env:"LOG_LEVEL,oneof=debug|info|warn|error,fuzzy"`
+		LogFormat *string `env:"LOG_FORMAT,fuzzy,oneof=json|logfmt"`
+		Region    string  `env:"REGION,oneof=us_east|us-west,fuzzy"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LOG_LEVEL":  "Warn",
+		"LOG_FORMAT": "LogFmt",
+		"REGION":     "US-East",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := "logfmt"
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("warn", config.LogLevel, "LogLevel should be the canonical value")
+	require.Equal(&expected, config.LogFormat, "LogFormat should be the canonical value")
+	require.Equal("us_east", config.Region, "Region should be the canonical value")
+}
+
+func TestOneOfFuzzyNotInEnum(t *testing.T) {
+	type Config struct {
+		LogLevel string `env:"LOG_LEVEL,oneof=debug|info,fuzzy"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LOG_LEVEL": "Warn",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrNotInEnum("LOG_LEVEL", "Warn", []string{"debug", "info"})
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because warn is not allowed")
+}
+
+func TestOneOfNotFuzzy(t *testing.T) {
+	type Config struct {
+		LogLevel string `env:"LOG_LEVEL,oneof=debug|info"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LOG_LEVEL": "Info",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrNotInEnum("LOG_LEVEL", "Info", []string{"debug", "info"})
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because matching is exact without fuzzy")
+}
+
+func TestFuzzyWithoutOneOf(t *testing.T) {
+	type Config struct {
+		LogLevel string `env:"LOG_LEVEL,fuzzy"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("LOG_LEVEL,fuzzy", "fuzzy")
+
+	require := require.New(t)
+	require.Equal(expected, err, "fuzzy should require oneof")
+}
+
+func TestStdin(t *testing.T) {
+	type Config struct {
+		VarA []byte `env:"VAR_A,stdin"`
+		VarB string `env:"VAR_B,stdin"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "-",
+		"VAR_B": "not-stdin",
+	})
+	p.Stdin = bytes.NewBufferString("from stdin")
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]byte("from stdin"), config.VarA, "VarA should be read from stdin")
+	require.Equal("not-stdin", config.VarB, "VarB should be used as-is")
+}
+
+func TestStdinBase64(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,stdin,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "-",
+	})
+	p.Stdin = bytes.NewBufferString("VkFMX0E=")
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should be read from stdin and base64-decoded")
+}
+
+func TestWithoutStdin(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "-",
+	})
+	p.Stdin = bytes.NewBufferString("from stdin")
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("-", config.VarA, "VarA should not be read from stdin without the option")
+}
+
+func TestStdinNotStringOrBytes(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,stdin"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,stdin", "stdin")
+
+	require := require.New(t)
+	require.Equal(expected, err, "stdin should only apply to strings and []byte")
+}
+
+func TestFromFile(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD_FILE,fromfile"`
+		Port     int    `env:"PORT_FILE,fromfile"`
+	}
+
+	dir := t.TempDir()
+	passwordPath := filepath.Join(dir, "password")
+	portPath := filepath.Join(dir, "port")
+	require.NoError(t, os.WriteFile(passwordPath, []byte("s3cret\n"), 0o600), "WriteFile should not fail")
+	require.NoError(t, os.WriteFile(portPath, []byte("5432"), 0o600), "WriteFile should not fail")
+
+	p := mapToParser(map[string]string{
+		"PASSWORD_FILE": passwordPath,
+		"PORT_FILE":     portPath,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("s3cret", config.Password, "Password should be read from the file without the newline")
+	require.Equal(5432, config.Port, "Port should be parsed from the contents of the file")
+}
+
+func TestFromFileBytes(t *testing.T) {
+	type Config struct {
+		Cert []byte `env:"TLS_CERT,fromfile"`
+		Key  []byte `env:"TLS_KEY,fromfile,base64"`
+	}
+
+	binary := []byte{0x00, 0xff, 0x0d, 0x0a, 0x80, 0x0a}
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert")
+	keyPath := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(certPath, binary, 0o600), "WriteFile should not fail")
+	require.NoError(t, os.WriteFile(keyPath, []byte("AP8=\n"), 0o600), "WriteFile should not fail")
+
+	p := mapToParser(map[string]string{
+		"TLS_CERT": certPath,
+		"TLS_KEY":  keyPath,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(binary, config.Cert, "Cert should hold the exact bytes of the file, including the trailing newline")
+	require.Equal([]byte{0x00, 0xff}, config.Key, "Key should be decoded from the file without the newline")
+}
+
+func TestFromFileBytesMissing(t *testing.T) {
+	type Config struct {
+		Cert []byte `env:"TLS_CERT,fromfile"`
+	}
+
+	path := filepath.Join(t.TempDir(), "missing")
+	p := mapToParser(map[string]string{
+		"TLS_CERT": path,
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrFileRead{}, err, "Get should fail with ErrFileRead")
+	require.Equal("TLS_CERT", err.(*libconfig.ErrFileRead).Key, "the error should name the variable")
+}
+
+func TestFromFileMissing(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD_FILE,fromfile"`
+	}
+
+	path := filepath.Join(t.TempDir(), "missing")
+	p := mapToParser(map[string]string{
+		"PASSWORD_FILE": path,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrFileRead)
+	require.True(ok, "the error should be ErrFileRead")
+	require.Equal("PASSWORD_FILE", specificErr.Key, "the error should be for PASSWORD_FILE")
+	require.Equal(path, specificErr.Path, "the error should include the path")
+	require.True(errors.Is(err, os.ErrNotExist), "the error should wrap the cause")
+}
+
+func TestFromFileWithStdin(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD_FILE,fromfile,stdin"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("PASSWORD_FILE,fromfile,stdin", "fromfile")
+
+	require := require.New(t)
+	require.Equal(expected, err, "fromfile and stdin should not be combined")
+}
+
+func TestMinMaxBoundaries(t *testing.T) {
+	type Config struct {
+		Port  int     `env:"PORT,min=1,max=65535"`
+		Count *uint8  `env:"COUNT,min=10"`
+		Ratio float64 `env:"RATIO,min=-0.5,max=0.5"`
+	}
+
+	require := require.New(t)
+
+	p := mapToParser(map[string]string{
+		"PORT":  "1",
+		"COUNT": "10",
+		"RATIO": "-0.5",
+	})
+	err := p.Get(&Config{})
+	require.NoError(err, "Get should not fail at the lower bounds")
+
+	p = mapToParser(map[string]string{
+		"PORT":  "65535",
+		"COUNT": "255",
+		"RATIO": "0.5",
+	})
+	err = p.Get(&Config{})
+	require.NoError(err, "Get should not fail at the upper bounds")
+}
+
+func TestMinMaxIntOutOfRange(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT,min=1,max=65535"`
+	}
+
+	require := require.New(t)
+
+	p := mapToParser(map[string]string{
+		"PORT": "0",
+	})
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOutOfRange("PORT", "0", "1", "65535")
+	require.Equal(expected, err, "Get should fail because the value is below the min")
+
+	p = mapToParser(map[string]string{
+		"PORT": "65536",
+	})
+	err = p.Get(&Config{})
+	expected = libconfig.NewErrOutOfRange("PORT", "65536", "1", "65535")
+	require.Equal(expected, err, "Get should fail because the value is above the max")
+}
+
+func TestMinMaxUintOutOfRange(t *testing.T) {
+	type Config struct {
+		Count *uint8 `env:"COUNT,min=10"`
+	}
+
+	p := mapToParser(map[string]string{
+		"COUNT": "9",
+	})
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOutOfRange("COUNT", "9", "10", "")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the value is below the min")
+}
+
+func TestMinMaxFloatOutOfRange(t *testing.T) {
+	type Config struct {
+		Ratio float64 `env:"RATIO,min=-0.5,max=0.5"`
+	}
+
+	require := require.New(t)
+
+	p := mapToParser(map[string]string{
+		"RATIO": "-0.51",
+	})
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOutOfRange("RATIO", "-0.51", "-0.5", "0.5")
+	require.Equal(expected, err, "Get should fail because the value is below the min")
+
+	p = mapToParser(map[string]string{
+		"RATIO": "0.51",
+	})
+	err = p.Get(&Config{})
+	expected = libconfig.NewErrOutOfRange("RATIO", "0.51", "-0.5", "0.5")
+	require.Equal(expected, err, "Get should fail because the value is above the max")
+}
+
+func TestMinMaxNotNumeric(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,min=1"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,min=1", "min=1")
+
+	require := require.New(t)
+	require.Equal(expected, err, "min should only apply to numbers")
+}
+
+func TestMinMaxInvalidBound(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,max=1.5"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,max=1.5", "max=1.5")
+
+	require := require.New(t)
+	require.Equal(expected, err, "max should parse as the kind of the field")
+}
+
+func TestOnResult(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	var namespaces []string
+	var errs []error
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+	p.Namespace = "billing"
+	p.OnResult = func(namespace string, err error) {
+		namespaces = append(namespaces, namespace)
+		errs = append(errs, err)
+	}
+
+	require := require.New(t)
+
+	err := p.Get(&Config{})
+	require.NoError(err, "Get should not fail")
+	require.Equal([]string{"billing"}, namespaces, "OnResult should be called once with the namespace")
+	require.Equal([]error{nil}, errs, "OnResult should be called with a nil error")
+
+	p.LookupFn = mapToParser(nil).LookupFn
+	err = p.Get(&Config{})
+	expected := libconfig.NewErrNamespace("billing", libconfig.NewErrVarNotFound("VAR_A"))
+	require.Equal(expected, err, "Get should fail with the namespace")
+	require.Equal("billing: var not found for key [VAR_A]", err.Error(), "the namespace should prefix the error")
+	require.Equal([]string{"billing", "billing"}, namespaces, "OnResult should be called once more")
+	require.Equal([]error{nil, expected}, errs, "OnResult should be called with the returned error")
+}
+
+func TestOnResultWithoutNamespace(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	var result error
+	p := mapToParser(nil)
+	p.OnResult = func(namespace string, err error) {
+		result = err
+	}
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrVarNotFound("VAR_A")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail without wrapping the error")
+	require.Equal(expected, result, "OnResult should be called with the returned error")
+}
+
+func TestMinLenMaxLen(t *testing.T) {
+	type Config struct {
+		Token  string  `env:"TOKEN,minlen=2,maxlen=4"`
+		Secret []byte  `env:"SECRET,minlen=4"`
+		Name   *string `env:"NAME,maxlen=2"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TOKEN":  "日本語!",
+		"SECRET": "日本",
+		"NAME":   "ab",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("日本語!", config.Token, "Token should be 4 runes")
+	require.Equal([]byte("日本"), config.Secret, "Secret should be 6 bytes")
+}
+
+func TestMinLenViolation(t *testing.T) {
+	type Config struct {
+		Secret []byte `env:"SECRET,minlen=4"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SECRET": "abc",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrLengthViolation("SECRET", 3, "4", "")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the value is too short")
+}
+
+func TestMaxLenViolation(t *testing.T) {
+	type Config struct {
+		Token string `env:"TOKEN,minlen=2,maxlen=4"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TOKEN": "日本語!?",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrLengthViolation("TOKEN", 5, "2", "4")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the value is too long")
+}
+
+func TestMinLenNotStringOrBytes(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,minlen=1"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,minlen=1", "minlen=1")
+
+	require := require.New(t)
+	require.Equal(expected, err, "minlen should only apply to strings and []byte")
+}
+
+func TestMaxLenInvalid(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,maxlen=-1"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,maxlen=-1", "maxlen=-1")
+
+	require := require.New(t)
+	require.Equal(expected, err, "maxlen should be a non-negative integer")
+}
+
+func TestPattern(t *testing.T) {
+	type Config struct {
+		Email string  `env:"ADMIN_EMAIL,pattern=^[^@]+@[^@]+$"`
+		Code  *string `env:"CODE,optional,pattern=^[A-Z]{2,3}$"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADMIN_EMAIL": "admin@example.com",
+		"CODE":        "ABC",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := "ABC"
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("admin@example.com", config.Email, "Email should parse correctly")
+	require.Equal(&expected, config.Code, "Code should parse correctly")
+}
+
+func TestPatternMismatch(t *testing.T) {
+	type Config struct {
+		Email string `env:"ADMIN_EMAIL,pattern=^[^@]+@[^@]+$"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADMIN_EMAIL": "not-an-email",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrPatternMismatch("ADMIN_EMAIL", "not-an-email", "^[^@]+@[^@]+$")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the value does not match")
+}
+
+func TestPatternInvalid(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,pattern=("`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail because the pattern is invalid")
+	specificErr, ok := err.(*libconfig.ErrInvalidPattern)
+	require.True(ok, "the error should be ErrInvalidPattern")
+	require.Equal("(", specificErr.Pattern, "the error should contain the pattern")
+	require.Error(specificErr.Because, "Because should be set")
+}
+
+func TestPatternNotString(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A,pattern=^1$"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,pattern=^1$", "pattern=^1$")
+
+	require := require.New(t)
+	require.Equal(expected, err, "pattern should only apply to strings")
+}
+
+func TestPatternFollowedByOption(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,pattern=x,optional"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,pattern=x,optional", "pattern=x")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail rather than take optional as part of the pattern")
+}
+
+func TestUnitDuration(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration  `env:"TIMEOUT,unit"`
+		Interval *time.Duration `env:"INTERVAL,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TIMEOUT":  "1h30m",
+		"INTERVAL": "01:02:03",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := time.Hour + 2*time.Minute + 3*time.Second
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(90*time.Minute, config.Timeout, "Timeout should parse as a duration")
+	require.Equal(&expected, config.Interval, "Interval should parse as a clock time")
+}
+
+func TestUnitDurationInvalid(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `env:"TIMEOUT,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TIMEOUT": "01:60:00",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the clock time")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal(reflect.Int64, specificErr.Kind, "the error should be for the duration")
+}
+
+func TestUnitDurationBareNumber(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration  `env:"TIMEOUT,unit=s"`
+		Suffixed time.Duration  `env:"SUFFIXED,unit=s"`
+		Delay    *time.Duration `env:"DELAY,unit=ms"`
+		Window   time.Duration  `env:"WINDOW,unit=m"`
+		Clock    time.Duration  `env:"CLOCK,unit=m"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TIMEOUT":  "30",
+		"SUFFIXED": "30s",
+		"DELAY":    "1.5",
+		"WINDOW":   "5",
+		"CLOCK":    "00:00:05",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := 1500 * time.Microsecond
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(30*time.Second, config.Timeout, "Timeout should be a number of seconds")
+	require.Equal(30*time.Second, config.Suffixed, "Suffixed should ignore the unit")
+	require.Equal(&expected, config.Delay, "Delay should be a number of milliseconds")
+	require.Equal(5*time.Minute, config.Window, "Window should be a number of minutes")
+	require.Equal(5*time.Second, config.Clock, "Clock should parse as a clock time")
+}
+
+func TestUnitDurationBareNumberWithoutUnit(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `env:"TIMEOUT,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TIMEOUT": "30",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "Get should fail because the number has no unit")
+}
+
+func TestUnitDurationInvalidUnit(t *testing.T) {
+	type Config struct {
+		Size int64 `env:"SIZE,unit=s"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("SIZE,unit=s", "unit=s")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because a duration unit requires a time.Duration")
+}
+
+func TestUnitByteSize(t *testing.T) {
+	type Config struct {
+		MaxUpload int64  `env:"MAX_UPLOAD,unit"`
+		Buffer    uint32 `env:"BUFFER,unit"`
+		Plain     int    `env:"PLAIN,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX_UPLOAD": "1.5GiB",
+		"BUFFER":     "10 MB",
+		"PLAIN":      "512",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(int64(1536*1024*1024), config.MaxUpload, "MaxUpload should parse as a binary size")
+	require.Equal(uint32(10000000), config.Buffer, "Buffer should parse as a decimal size")
+	require.Equal(512, config.Plain, "Plain should parse as bytes")
+}
+
+func TestUnitByteSizeOverflow(t *testing.T) {
+	type Config struct {
+		Buffer uint16 `env:"BUFFER,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BUFFER": "1MiB",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Uint16, "BUFFER", "1MiB")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because 1MiB overflows uint16")
+}
+
+func TestUnitByteSizeBoundaries(t *testing.T) {
+	type Config struct {
+		MaxInt  int64  `env:"MAX_INT,unit"`
+		MinInt  int64  `env:"MIN_INT,unit"`
+		MaxUint uint64 `env:"MAX_UINT,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX_INT":  "9223372036854775807",
+		"MIN_INT":  "-9223372036854775808",
+		"MAX_UINT": "18446744073709551615",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(int64(math.MaxInt64), config.MaxInt, "MaxInt should be exact")
+	require.Equal(int64(math.MinInt64), config.MinInt, "MinInt should be exact")
+	require.Equal(uint64(math.MaxUint64), config.MaxUint, "MaxUint should be exact")
+}
+
+func TestUnitByteSizeBoundaryOverflowInt64(t *testing.T) {
+	type Config struct {
+		Size int64 `env:"SIZE,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SIZE": "9223372036854775808",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOverflow(reflect.Int64, "SIZE", "9223372036854775808")
+
+	require.Equal(t, expected, err, "Get should fail because 2^63 overflows int64")
+}
+
+func TestUnitByteSizeBoundaryOverflowUint64(t *testing.T) {
+	type Config struct {
+		Size uint64 `env:"SIZE,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SIZE": "18446744073709551616",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOverflow(reflect.Uint64, "SIZE", "18446744073709551616")
+
+	require.Equal(t, expected, err, "Get should fail because 2^64 overflows uint64")
+}
+
+func TestUnitByteSizeScaledBoundaryOverflow(t *testing.T) {
+	type Config struct {
+		Size int64 `env:"SIZE,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SIZE": "8589934592GiB",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrOverflow(reflect.Int64, "SIZE", "8589934592GiB")
+
+	require.Equal(t, expected, err, "Get should fail because 8589934592GiB is 2^63 bytes")
+}
+
+func TestUnitByteSizeInvalid(t *testing.T) {
+	type Config struct {
+		Buffer int `env:"BUFFER,unit"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BUFFER": "10 parsecs",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the unit")
+	_, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+}
+
+func TestMinutes(t *testing.T) {
+	type Config struct {
+		Interval int   `env:"INTERVAL,minutes"`
+		Rounded  int32 `env:"ROUNDED,minutes=round"`
+		Negative int   `env:"NEGATIVE,minutes=round"`
+	}
+
+	p := mapToParser(map[string]string{
+		"INTERVAL": "1h30m",
+		"ROUNDED":  "90s",
+		"NEGATIVE": "-90s",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(90, config.Interval, "Interval should be in minutes")
+	require.Equal(int32(1), config.Rounded, "Rounded should be rounded down to whole minutes")
+	require.Equal(-2, config.Negative, "Negative should be rounded down to whole minutes")
+}
+
+func TestMinutesPartial(t *testing.T) {
+	type Config struct {
+		Interval int `env:"INTERVAL,minutes"`
+	}
+
+	p := mapToParser(map[string]string{
+		"INTERVAL": "90s",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrCannotParseEnv(libconfig.ErrPartialMinute, reflect.Int, "INTERVAL", "90s")
+
+	require.Equal(t, expected, err, "Get should fail because 90s is not a whole number of minutes")
+}
+
+func TestMinutesInvalid(t *testing.T) {
+	type Config struct {
+		Interval int `env:"INTERVAL,minutes"`
+	}
+
+	p := mapToParser(map[string]string{
+		"INTERVAL": "90",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	var specificErr *libconfig.ErrCannotParseEnv
+	require.ErrorAs(err, &specificErr, "the error should be ErrCannotParseEnv")
+	require.Equal("INTERVAL", specificErr.Key, "the error should be for INTERVAL")
+}
+
+func TestMinutesInvalidType(t *testing.T) {
+	type Config struct {
+		Interval uint `env:"INTERVAL,minutes=ceil"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("INTERVAL,minutes=ceil", "minutes=ceil")
+
+	require.Equal(t, expected, err, "minutes only accepts round")
+}
+
+func TestUnitWithoutSemantics(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,unit"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,unit", "unit")
+
+	require := require.New(t)
+	require.Equal(expected, err, "unit should only apply to durations and integers")
+}
+
+func TestBase64GzipJSON(t *testing.T) {
+	type Nested struct {
+		VarC int    `json:"varc"`
+		VarD string `json:"vard"`
+	}
+	type Config struct {
+		Nested *Nested `env:"NESTED,json,gzip,base64"`
+		VarA   string  `env:"VAR_A,base64,gzip"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": gzipBase64(t, `{"varc": 10, "vard": "val_d"}`),
+		"VAR_A":  gzipBase64(t, "VAL_A"),
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := &Nested{VarC: 10, VarD: "val_d"}
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.Nested, "Nested should be base64-decoded, gunzipped, and then parsed as JSON")
+	require.Equal("VAL_A", config.VarA, "VarA should be base64-decoded and gunzipped")
+}
+
+func TestGzipInvalid(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,base64,gzip"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VkFMX0E=",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	// Note that we do not actually expect a nil error.
+	// We care (and test below) that an error is present, but not the error itself.
+	expected := libconfig.NewErrDecodeFailure(nil, "VAR_A", "VkFMX0E=", "gzip")
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to gunzip the value")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Error(specificErr.Because, "Because should be set")
+	specificErr.Because = nil // clear the underlying error so that we can validate the rest of the struct using `expected`
+	require.Equal(expected, err, "Get should fail to gunzip the value")
+}
+
+func TestGzipWithoutBase64(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,gzip"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,gzip", "gzip")
+
+	require := require.New(t)
+	require.Equal(expected, err, "gzip should require base64")
+}
+
+func TestGetWithMeta(t *testing.T) {
+	type Config struct {
+		VarA   string  `env:"VAR_A"`
+		VarB   string  `env:"VAR_B,optional"`
+		VarC   *string `env:"VAR_C,optional"`
+		Nested struct {
+			VarD int `env:"VAR_D,optional"`
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "",
+		"VAR_D": "10",
+	})
+
+	config := Config{}
+	found, err := p.GetWithMeta(&config)
+	expected := map[string]bool{
+		"VAR_A": true,
+		"VAR_B": false,
+		"VAR_C": false,
+		"VAR_D": true,
+	}
+
+	require := require.New(t)
+	require.NoError(err, "GetWithMeta should not fail")
+	require.Equal(expected, found, "found should record whether each var was found")
+	require.Equal("", config.VarA, "VarA should be explicitly empty")
+	require.Equal(10, config.Nested.VarD, "VarD should parse correctly")
+}
+
+func TestGetWithMetaError(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional"`
+		VarB string `env:"VAR_B"`
+		VarC string `env:"VAR_C"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+
+	found, err := p.GetWithMeta(&Config{})
+	expected := map[string]bool{
+		"VAR_A": true,
+		"VAR_B": false,
+	}
+
+	require := require.New(t)
+	require.Equal(libconfig.NewErrVarNotFound("VAR_B"), err, "GetWithMeta should fail because VAR_B is missing")
+	require.Equal(expected, found, "found should contain the vars consulted before the error")
+}
+
+func TestGetWithAnyFound(t *testing.T) {
+	type Config struct {
+		VarA   string `env:"VAR_A,optional"`
+		Nested struct {
+			VarB int `env:"VAR_B,optional"`
+		}
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_B": "10",
+	})
+
+	config := Config{}
+	anyFound, err := p.GetWithAnyFound(&config)
+
+	require := require.New(t)
+	require.NoError(err, "GetWithAnyFound should not fail")
+	require.True(anyFound, "VAR_B was found")
+	require.Equal(10, config.Nested.VarB, "VarB should parse correctly")
+}
+
+func TestGetWithAnyFoundNone(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional"`
+		VarB int    `env:"VAR_B,optional,default=5"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{}
+	anyFound, err := p.GetWithAnyFound(&config)
+
+	require := require.New(t)
+	require.NoError(err, "GetWithAnyFound should not fail")
+	require.False(anyFound, "no variables were found, and defaults do not count")
+	require.Equal(5, config.VarB, "VarB should have its default")
+}
+
+func TestGetMap(t *testing.T) {
+	p := mapToParser(map[string]string{
+		"APP_REGION": "us-east",
+		"APP_ZONE":   "",
+	})
+	p.Prefix = "APP_"
+
+	values, err := p.GetMap([]string{"REGION", "ZONE"})
+	expected := map[string]string{
+		"REGION": "us-east",
+		"ZONE":   "",
+	}
+
+	require := require.New(t)
+	require.NoError(err, "GetMap should not fail")
+	require.Equal(expected, values, "the values should be keyed by the names as given")
+}
+
+func TestGetMapMissing(t *testing.T) {
+	p := mapToParser(map[string]string{
+		"APP_REGION": "us-east",
+	})
+	p.Prefix = "APP_"
+
+	values, err := p.GetMap([]string{"REGION", "ZONE"})
+	expected := libconfig.NewErrVarNotFound("APP_ZONE")
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetMap should fail because APP_ZONE is missing")
+	require.Nil(values, "no values should be returned")
+}
+
+func TestGetMapOptional(t *testing.T) {
+	p := mapToParser(map[string]string{
+		"REGION": "us-east",
+	})
+
+	values, err := p.GetMapOptional([]string{"REGION", "ZONE"})
+	expected := map[string]string{
+		"REGION": "us-east",
+	}
+
+	require := require.New(t)
+	require.NoError(err, "GetMapOptional should not fail")
+	require.Equal(expected, values, "missing names should be omitted")
+}
+
+func TestNewMapParser(t *testing.T) {
+	type Config struct {
+		VarA string `cfg:"VAR_A"`
+		VarB string `cfg:"VAR_B"`
+		VarC string `cfg:"VAR_C,optional"`
+	}
+
+	p := libconfig.NewMapParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_B": "",
+		"VAR_D": "VAL_D",
+	}, "cfg")
+
+	config := Config{
+		VarC: "default",
+	}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("VAL_A", config.VarA, "VarA should come from the map")
+	require.Equal("", config.VarB, "VarB should be found even though it is empty")
+	require.Equal("default", config.VarC, "VarC should keep its default")
+
+	unused, err := p.UnusedVars(&config)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"VAR_D"}, unused, "UnusedVars should enumerate the map")
+}
+
+func TestChar(t *testing.T) {
+	type Config struct {
+		Sep     rune  `env:"SEP,char"`
+		Accent  int32 `env:"ACCENT,char"`
+		Emoji   *rune `env:"EMOJI,char"`
+		Byte    byte  `env:"BYTE,char"`
+		Default rune  `env:"DEFAULT,char,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SEP":    ",",
+		"ACCENT": "é",
+		"EMOJI":  "🙂",
+		"BYTE":   "x",
+	})
+	config := Config{
+		Default: ';',
+	}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(',', config.Sep, "Sep should be a comma")
+	require.Equal('é', config.Accent, "Accent should be the two-byte character")
+	require.Equal('🙂', *config.Emoji, "Emoji should be the four-byte character")
+	require.Equal(byte('x'), config.Byte, "Byte should be the character")
+	require.Equal(';', config.Default, "Default should keep its default")
+}
+
+func TestCharMultipleRunes(t *testing.T) {
+	type Config struct {
+		Sep rune `env:"SEP,char"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SEP": "é,",
+	})
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("SEP", specificErr.Key, "the error should be for SEP")
+}
+
+func TestCharEmpty(t *testing.T) {
+	type Config struct {
+		Sep rune `env:"SEP,char"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SEP": "",
+	})
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrCannotParseEnv{}, err, "Get should fail because the value is empty")
+}
+
+func TestCharOverflow(t *testing.T) {
+	type Config struct {
+		Byte byte `env:"BYTE,char"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BYTE": "€",
+	})
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Uint8, "BYTE", "€")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because € does not fit in a byte")
+}
+
+func TestCharInvalidType(t *testing.T) {
+	type Config struct {
+		Sep string `env:"SEP,char"`
+	}
+
+	p := mapToParser(nil)
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrInvalidTagOption("SEP,char", "char")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because char requires an integer")
+}
+
+func TestGetContextCancelledBetweenFields(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B"`
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var looked []string
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn: func(key string) (string, bool) {
+			looked = append(looked, key)
+			cancel()
+			return "value", true
+		},
+	}
+
+	config := Config{}
+	err := p.GetContext(ctx, &config)
+	expected := libconfig.NewErrLookupFailed("VAR_B", context.Canceled)
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetContext should stop once the context is cancelled")
+	require.True(errors.Is(err, context.Canceled), "the error should wrap the context error")
+	require.Equal([]string{"VAR_A"}, looked, "VAR_B should not be looked up")
+	require.Equal("value", config.VarA, "VarA should be set")
+}
+
+func TestLookupCtxFn(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,optional"`
+	}
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "from-context")
+
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn: func(key string) (string, bool) {
+			return "from-lookup-fn", true
+		},
+		LookupCtxFn: func(ctx context.Context, key string) (string, bool, error) {
+			if key == "VAR_B" {
+				return "", false, nil
+			}
+			return ctx.Value(ctxKey{}).(string), true, nil
+		},
+	}
+
+	config := Config{}
+	err := p.GetContext(ctx, &config)
+
+	require := require.New(t)
+	require.NoError(err, "GetContext should not fail")
+	require.Equal("from-context", config.VarA, "LookupCtxFn should take precedence over LookupFn")
+	require.Equal("", config.VarB, "VarB should not be found")
+}
+
+func TestLookupCtxFnError(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional"`
+	}
+
+	cause := fmt.Errorf("secrets store unavailable")
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupCtxFn: func(ctx context.Context, key string) (string, bool, error) {
+			return "", false, cause
+		},
+	}
+
+	config := Config{}
+	err := p.GetContext(context.Background(), &config)
+	expected := libconfig.NewErrLookupFailed("VAR_A", cause)
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetContext should surface the lookup error even for optional fields")
+}
+
+func TestLookupFn2(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `env:"VAR_B,optional"`
+	}
+
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn: func(key string) (string, bool) {
+			return "from-lookup-fn", true
+		},
+		LookupFn2: func(key string) (string, bool, error) {
+			if key == "VAR_B" {
+				return "", false, nil
+			}
+			return "from-lookup-fn2", true, nil
+		},
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("from-lookup-fn2", config.VarA, "LookupFn2 should take precedence over LookupFn")
+	require.Equal("", config.VarB, "VarB should not be found")
+}
+
+func TestLookupFn2Error(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	cause := fmt.Errorf("connection refused")
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn2: func(key string) (string, bool, error) {
+			return "", false, cause
+		},
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrLookupFailed("VAR_A", cause)
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail with ErrLookupFailed")
+	require.False(errors.Is(err, libconfig.ErrNotFound), "the error should not be ErrVarNotFound")
+}
+
+func TestParseValue(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+
+	require := require.New(t)
+
+	config := Config{}
+	err := p.ParseValue(reflect.ValueOf(&config).Elem())
+	require.NoError(err, "ParseValue should not fail for an addressable struct")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+
+	config = Config{}
+	err = p.ParseValue(reflect.ValueOf(&config))
+	require.NoError(err, "ParseValue should not fail for a pointer to a struct")
+	require.Equal("VAL_A", config.VarA, "VarA should parse correctly")
+}
+
+func TestParseValueNotAddressable(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+
+	err := p.ParseValue(reflect.ValueOf(Config{}))
+	expected := libconfig.NewErrNotAddressable(reflect.TypeOf(Config{}))
+
+	require := require.New(t)
+	require.Equal(expected, err, "ParseValue should fail because the struct cannot be set")
+}
+
+func TestParseValueInvalidType(t *testing.T) {
+	p := mapToParser(nil)
+
+	require := require.New(t)
+
+	err := p.ParseValue(reflect.ValueOf(42))
+	require.Equal(libconfig.NewErrInvalidConfigType(reflect.TypeOf(42)), err, "ParseValue should fail for an int")
+
+	err = p.ParseValue(reflect.Value{})
+	require.Equal(libconfig.NewErrInvalidConfigType(nil), err, "ParseValue should fail for the zero Value")
+}
+
+func TestBase64List(t *testing.T) {
+	type Config struct {
+		Blobs  [][]byte `env:"BLOBS,base64"`
+		Zipped [][]byte `env:"ZIPPED,base64,gzip"`
+		Empty  [][]byte `env:"EMPTY,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BLOBS":  "YQ==, Yg==,Yw==",
+		"ZIPPED": gzipBase64(t, "a") + "," + gzipBase64(t, "b"),
+		"EMPTY":  "",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([][]byte{[]byte("a"), []byte("b"), []byte("c")}, config.Blobs, "Blobs should contain each decoded item")
+	require.Equal([][]byte{[]byte("a"), []byte("b")}, config.Zipped, "Zipped should contain each decompressed item")
+	require.Equal([][]byte{}, config.Empty, "Empty should contain no items")
+}
+
+func TestBase64ListInvalidItem(t *testing.T) {
+	type Config struct {
+		Blobs [][]byte `env:"BLOBS,base64"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BLOBS": "YQ==,not base64,Yw==",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("BLOBS[1]", specificErr.Key, "the error should identify the index")
+	require.Equal("base64", specificErr.Type, "the error should be for base64")
+}
+
+func mapToParser(envs map[string]string) libconfig.Parser {
+	return *libconfig.NewMapParser(envs, "env")
+}
+
+func gzipBase64(t *testing.T, value string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(value))
+	require.NoError(t, err, "gzip should not fail")
+	require.NoError(t, w.Close(), "gzip should not fail")
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestAtomic(t *testing.T) {
+	type Config struct {
+		Int32   atomic.Int32   `env:"INT32"`
+		Int64   atomic.Int64   `env:"INT64"`
+		Uint32  atomic.Uint32  `env:"UINT32"`
+		Uint64  *atomic.Uint64 `env:"UINT64"`
+		Bool    atomic.Bool    `env:"BOOL"`
+		Missing atomic.Int64   `env:"MISSING,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"INT32":  "-32",
+		"INT64":  "64",
+		"UINT32": "32",
+		"UINT64": "64",
+		"BOOL":   "true",
+	})
+
+	config := Config{}
+	config.Missing.Store(7)
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(int32(-32), config.Int32.Load(), "Int32 should be stored")
+	require.Equal(int64(64), config.Int64.Load(), "Int64 should be stored")
+	require.Equal(uint32(32), config.Uint32.Load(), "Uint32 should be stored")
+	require.Equal(uint64(64), config.Uint64.Load(), "Uint64 should be allocated and stored")
+	require.True(config.Bool.Load(), "Bool should be stored")
+	require.Equal(int64(7), config.Missing.Load(), "Missing should keep its default")
+}
+
+func TestAtomicOverflow(t *testing.T) {
+	type Config struct {
+		Int32 atomic.Int32 `env:"INT32"`
+	}
+
+	p := mapToParser(map[string]string{
+		"INT32": "4294967296",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Int32, "INT32", "4294967296")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the value does not fit")
+	require.Equal(int32(0), config.Int32.Load(), "Int32 should not be stored")
+}
+
+func TestTags(t *testing.T) {
+	type Legacy struct {
+		Port int `envconfig:"PORT"`
+	}
+	type Config struct {
+		VarA string `env:"VAR_A"`
+		VarB string `envconfig:"VAR_B"`
+		VarC string `env:"VAR_C" envconfig:"LEGACY_C"`
+		Legacy
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":    "a",
+		"VAR_B":    "b",
+		"VAR_C":    "c",
+		"LEGACY_C": "legacy",
+		"PORT":     "8080",
+	})
+	p.Tags = []string{"env", "envconfig"}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("a", config.VarA, "VarA should use the env tag")
+	require.Equal("b", config.VarB, "VarB should use the envconfig tag")
+	require.Equal("c", config.VarC, "the first tag present should win")
+	require.Equal(8080, config.Port, "Port should use the envconfig tag")
+}
+
+func TestTagsOverridesTag(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A" conf:"CONF_A"`
+	}
+
+	p := mapToParser(map[string]string{"VAR_A": "env", "CONF_A": "conf"})
+	p.Tags = []string{"conf"}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("conf", config.VarA, "Tags should be used instead of Tag")
+}
+
+func TestAliasFn(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,alt=PORT_NUMBER"`
+		Name string `env:"NAME,optional"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PLATFORM_APP_HOST":        "example.com",
+		"PLATFORM_APP_PORT_NUMBER": "8080",
+		"APP_NAME":                 "ignored",
+	})
+	p.Prefix = "APP_"
+	p.AliasFn = func(name string) string {
+		return "PLATFORM_" + name
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("example.com", config.Host, "Host should be looked up by its alias")
+	require.Equal(8080, config.Port, "Port should be looked up by the alias of its alternate name")
+	require.Equal("", config.Name, "Name should only be looked up by its alias")
+}
+
+func TestAliasFnNotFound(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+	}
+
+	p := mapToParser(map[string]string{"HOST": "example.com"})
+	p.AliasFn = strings.ToLower
+
+	config := Config{}
+	err := p.Get(&config)
+
+	expected := libconfig.NewErrVarNotFound("HOST")
+	require.Equal(t, expected, err, "the error should name the variable before mapping")
 }
@@ -0,0 +1,51 @@
+package libconfig
+
+// Logger receives debug messages that narrate how Get parses a config, e.g. to
+// troubleshoot a misconfiguration in the field. The values of fields tagged as secret
+// and the passphrases of fields tagged with kdf are redacted. Unlike OnSet, the
+// messages are meant for people rather than code.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+// logValue returns the value to log for the tag, redacting the value of a secret or
+// a passphrase for a kdf
+func logValue(tag tagData, value string) string {
+	if tag.Secret || tag.KDF != "" {
+		return Redacted
+	}
+
+	return value
+}
+
+// decodings returns the names of the options that transform the value for the tag
+// before it is set, in the order in which they are applied
+func (t tagData) decodings() []string {
+	var steps []string
+	for _, step := range []struct {
+		name    string
+		applies bool
+	}{
+		{"expand", t.Expand},
+		{"stdin", t.Stdin},
+		{"fromfile", t.FromFile},
+		{"base64", t.Base64},
+		{"hex", t.Hex},
+		{"gzip", t.Gzip},
+		{"kdf=" + t.KDF, t.KDF != ""},
+		{"poscsv", t.PosCSV},
+		{"csv", t.CSV},
+		{"json", t.JSON},
+		{t.Unmarshaler, t.Unmarshaler != ""},
+		{"thousands=" + t.Thousands, t.Thousands != ""},
+		{"unit", t.Unit},
+		{"minutes", t.Minutes},
+		{"char", t.Char},
+	} {
+		if step.applies {
+			steps = append(steps, step.name)
+		}
+	}
+
+	return steps
+}
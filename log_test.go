@@ -0,0 +1,145 @@
+package libconfig_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+// recordingLogger records each message it receives
+type recordingLogger struct {
+	entries []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	type Config struct {
+		Host     string `env:"HOST"`
+		Port     int    `env:"PORT,default=5432"`
+		Password string `env:"PASSWORD,base64,secret"`
+		Debug    bool   `env:"DEBUG,optional"`
+		Nested   struct {
+			Name string `env:"NAME"`
+		} `env:"APP_,prefix"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOST":     "localhost",
+		"PASSWORD": "aHVudGVyMg==",
+		"APP_NAME": "app",
+	})
+	logger := &recordingLogger{}
+	p.Logger = logger
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]string{
+		"libconfig: looked up [HOST] as [HOST], found: true",
+		"libconfig: setting [HOST] from lookup to [localhost], decoding with []",
+		"libconfig: looked up [PORT] as [PORT], found: false",
+		"libconfig: setting [PORT] from default to [5432], decoding with []",
+		"libconfig: looked up [PASSWORD] as [PASSWORD], found: true",
+		"libconfig: setting [PASSWORD] from lookup to [[REDACTED]], decoding with [base64]",
+		"libconfig: looked up [DEBUG] as [DEBUG], found: false",
+		"libconfig: [DEBUG] is optional and not found, keeping its current value",
+		"libconfig: parsing struct [Nested] with prefix [APP_]",
+		"libconfig: looked up [APP_NAME] as [APP_NAME], found: true",
+		"libconfig: setting [APP_NAME] from lookup to [app], decoding with []",
+	}, logger.entries, "the logger should narrate each field")
+	require.NotContains(strings.Join(logger.entries, "\n"), "aHVudGVyMg==", "the secret should be redacted")
+}
+
+func TestLoggerKDF(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PASSPHRASE": "hunter2",
+		"KEY_SALT":   "salt",
+	})
+	p.AllowKDF = true
+	p.KDFs = map[string]libconfig.KDFFunc{
+		"sha256": sha256KDF,
+	}
+	logger := &recordingLogger{}
+	p.Logger = logger
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Contains(logger.entries, "libconfig: setting [PASSPHRASE] from lookup to [[REDACTED]], decoding with [kdf=sha256]", "the passphrase should be redacted")
+	require.NotContains(strings.Join(logger.entries, "\n"), "hunter2", "the passphrase should never be logged")
+}
+
+func TestLoggerNil(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOST": "localhost",
+	})
+	logger := &recordingLogger{}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail without a logger")
+	require.Equal("localhost", config.Host, "Host should parse correctly")
+	require.Empty(logger.entries, "a logger that is not set should never be called")
+}
+
+func TestDeprecated(t *testing.T) {
+	type Config struct {
+		Old     string `env:"OLD,optional,deprecated"`
+		Unset   string `env:"UNSET,optional,deprecated"`
+		Current string `env:"CURRENT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"OLD":     "VAL_OLD",
+		"CURRENT": "VAL_CURRENT",
+	})
+	deprecated := []string{}
+	p.OnDeprecated = func(name string) {
+		deprecated = append(deprecated, name)
+	}
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]string{"OLD"}, deprecated, "OnDeprecated should only be called for the deprecated variable that is set")
+	require.Equal("VAL_OLD", config.Old, "the deprecated variable should still be parsed")
+}
+
+func TestDeprecatedLogger(t *testing.T) {
+	type Config struct {
+		Old string `env:"OLD,deprecated"`
+	}
+
+	p := mapToParser(map[string]string{
+		"OLD": "VAL_OLD",
+	})
+	logger := &recordingLogger{}
+	p.Logger = logger
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Contains(logger.entries, "libconfig: [OLD] is deprecated", "the Logger should warn without OnDeprecated")
+}
@@ -0,0 +1,105 @@
+package libconfig
+
+import (
+	"context"
+	"flag"
+	"strings"
+)
+
+// ChainLookup returns a lookup function that consults each of the lookup functions in
+// order and returns the first value found, e.g. to give flags precedence over the
+// environment:
+//
+//	libconfig.ChainLookup(libconfig.FlagLookup(fs), os.LookupEnv)
+func ChainLookup(fns ...func(string) (string, bool)) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		for _, fn := range fns {
+			if value, found := fn(key); found {
+				return value, true
+			}
+		}
+
+		return "", false
+	}
+}
+
+// FlagLookup returns a lookup function over the flags of the parsed FlagSet. Only flags
+// that were set on the command line are found, so that the defaults of the flags do not
+// hide other sources. Variable names are matched to flag names by lowercasing them and
+// replacing underscores with hyphens, so LOG_LEVEL matches the flag log-level (or
+// log_level). The names include any Prefix of the Parser.
+func FlagLookup(fs *flag.FlagSet) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		name := flagName(key)
+
+		var value string
+		var found bool
+		fs.Visit(func(f *flag.Flag) {
+			if !found && flagName(f.Name) == name {
+				value, found = f.Value.String(), true
+			}
+		})
+
+		return value, found
+	}
+}
+
+// With returns a shallow copy of the Parser whose lookup finds the value for the name
+// and otherwise delegates to the lookup function of the Parser, e.g. to force a single
+// field in a test or to inject a computed value. As for FlagLookup, the name includes
+// any Prefix of the Parser. The copy shares the decoders and lazy defaults registered
+// with the Parser, and its EnumFn, if any, also lists the name.
+func (p *Parser) With(name, value string) *Parser {
+	q := *p
+
+	// Only the lookup function that lookupVar uses needs the override
+	switch {
+	case p.LookupCtxFn != nil:
+		fn := p.LookupCtxFn
+		q.LookupCtxFn = func(ctx context.Context, key string) (string, bool, error) {
+			if key == name {
+				return value, true, nil
+			}
+			return fn(ctx, key)
+		}
+	case p.LookupFn2 != nil:
+		fn := p.LookupFn2
+		q.LookupFn2 = func(key string) (string, bool, error) {
+			if key == name {
+				return value, true, nil
+			}
+			return fn(key)
+		}
+	default:
+		fn := p.LookupFn
+		q.LookupFn = func(key string) (string, bool) {
+			if key == name {
+				return value, true
+			}
+			if fn == nil {
+				return "", false
+			}
+			return fn(key)
+		}
+	}
+
+	if p.EnumFn != nil {
+		enum := p.EnumFn
+		q.EnumFn = func() []string {
+			names := enum()
+			for _, n := range names {
+				if n == name {
+					return names
+				}
+			}
+			return append(names, name)
+		}
+	}
+
+	return &q
+}
+
+// flagName normalizes a variable or flag name, e.g. LOG_LEVEL becomes log-level
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
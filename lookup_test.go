@@ -0,0 +1,139 @@
+package libconfig_test
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestFlagLookup(t *testing.T) {
+	type Config struct {
+		LogLevel string        `env:"LOG_LEVEL"`
+		Timeout  time.Duration `env:"TIMEOUT,unit"`
+		Port     int           `env:"PORT"`
+		Verbose  bool          `env:"VERBOSE,optional"`
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("log-level", "info", "")
+	fs.Duration("timeout", time.Second, "")
+	fs.Int("port", 80, "")
+	fs.Bool("verbose", false, "")
+	err := fs.Parse([]string{"-log-level=debug", "-timeout=1m"})
+
+	require := require.New(t)
+	require.NoError(err, "Parse should not fail")
+
+	env := map[string]string{
+		"LOG_LEVEL": "warn",
+		"PORT":      "8080",
+	}
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn: libconfig.ChainLookup(libconfig.FlagLookup(fs), func(key string) (string, bool) {
+			value, found := env[key]
+			return value, found
+		}),
+	}
+
+	config := Config{}
+	err = p.Get(&config)
+
+	require.NoError(err, "Get should not fail")
+	require.Equal("debug", config.LogLevel, "the flag should take precedence over the environment")
+	require.Equal(time.Minute, config.Timeout, "Timeout should come from the flag")
+	require.Equal(8080, config.Port, "Port should come from the environment, not the flag default")
+	require.False(config.Verbose, "Verbose should not be found")
+}
+
+func TestFlagLookupUnderscore(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db_host", "", "")
+	err := fs.Parse([]string{"-db_host=localhost"})
+
+	require := require.New(t)
+	require.NoError(err, "Parse should not fail")
+
+	value, found := libconfig.FlagLookup(fs)("DB_HOST")
+	require.True(found, "DB_HOST should match db_host")
+	require.Equal("localhost", value, "the value should come from the flag")
+}
+
+func TestChainLookupNotFound(t *testing.T) {
+	lookup := libconfig.ChainLookup(func(string) (string, bool) { return "", false })
+
+	value, found := lookup("VAR_A")
+
+	require := require.New(t)
+	require.False(found, "VAR_A should not be found")
+	require.Equal("", value, "the value should be empty")
+}
+
+func TestWith(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOST": "db.example.com",
+		"PORT": "5432",
+	})
+	q := p.With("PORT", "6543")
+
+	config := Config{}
+	err := q.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("db.example.com", config.Host, "Host should be delegated to the original lookup")
+	require.Equal(6543, config.Port, "Port should be overridden")
+
+	config = Config{}
+	err = p.Get(&config)
+	require.NoError(err, "Get should not fail")
+	require.Equal(5432, config.Port, "the original Parser should not be changed")
+}
+
+func TestWithLookupFn2(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+
+	p := libconfig.Parser{
+		Tag: "env",
+		LookupFn2: func(key string) (string, bool, error) {
+			return "from-" + key, true, nil
+		},
+	}
+
+	config := Config{}
+	err := p.With("PORT", "80").Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("from-HOST", config.Host, "Host should be delegated to LookupFn2")
+	require.Equal(80, config.Port, "Port should be overridden")
+}
+
+func TestWithPrefixMap(t *testing.T) {
+	type Config struct {
+		Flags map[string]bool `env:"FLAG_,prefixmap"`
+	}
+
+	p := mapToParser(map[string]string{
+		"FLAG_A": "1",
+	})
+
+	config := Config{}
+	err := p.With("FLAG_B", "0").Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]bool{"A": true, "B": false}, config.Flags, "the EnumFn should list the override")
+}
@@ -0,0 +1,78 @@
+package libconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// isMap returns true if v is a map that should be parsed from key=value pairs, which
+// excludes maps whose type decodes itself with UnmarshalJSON
+func isMap(v reflect.Value) bool {
+	return v.Kind() == reflect.Map && !reflect.PtrTo(v.Type()).Implements(jsonUnmarshalerType)
+}
+
+// parseMap parses comma-separated key=value pairs, e.g. "a=1s,b=2m", into a new map.
+// The keys are parsed like any other value. Each value is parsed with the custom decoder
+// registered for the type of the map's values, if any, or as a duration if the values
+// are a time.Duration, or otherwise like any other value. Errors for a value identify
+// its key, e.g. TIMEOUTS[b].
+func (p *Parser) parseMap(state *getState, v reflect.Value, tag tagData, value string) error {
+	t := v.Type()
+	m := reflect.MakeMap(t)
+
+	if value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			rawKey, rawValue, ok := strings.Cut(entry, "=")
+			if !ok {
+				err := fmt.Errorf("entry [%s] is not of the form key=value", entry)
+				return NewErrDecodeFailure(err, tag.Name, value, "map")
+			}
+			rawKey = strings.TrimSpace(rawKey)
+			rawValue = strings.TrimSpace(rawValue)
+
+			key := reflect.New(t.Key()).Elem()
+			err := setValue(key, tag.elem(tag.Name), []byte(rawKey))
+			if err != nil {
+				return err
+			}
+
+			elem := reflect.New(t.Elem()).Elem()
+			err = p.assignMapValue(state, elem, tag.elem(tag.Name+"["+rawKey+"]"), rawValue)
+			if err != nil {
+				return err
+			}
+
+			m.SetMapIndex(key, elem)
+		}
+	}
+
+	v.Set(m)
+	return nil
+}
+
+// assignMapValue sets a single value of a map
+func (p *Parser) assignMapValue(state *getState, v reflect.Value, tag tagData, value string) error {
+	if ok, err := p.decode(state.ctx, v, tag, value); ok {
+		return err
+	}
+
+	if v.Kind() == reflect.Ptr && v.Type() != regexpType {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+
+		if ok, err := p.decode(state.ctx, v, tag, value); ok {
+			return err
+		}
+	}
+
+	// JSON has no duration literal, so durations are always parsed as such
+	if v.Type() == durationType {
+		return setValueWithUnits(v, tag.Name, value, 0)
+	}
+
+	return setValue(v, tag, []byte(value))
+}
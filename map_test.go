@@ -0,0 +1,131 @@
+package libconfig_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestMap(t *testing.T) {
+	type Config struct {
+		Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+		Weights  map[string]int           `env:"WEIGHTS"`
+		Ports    *map[int]uint16          `env:"PORTS"`
+		Empty    map[string]string        `env:"EMPTY"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TIMEOUTS": "a=1s, b=2m",
+		"WEIGHTS":  "x=1,y=-2",
+		"PORTS":    "1=80,2=443",
+		"EMPTY":    "",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]time.Duration{"a": time.Second, "b": 2 * time.Minute}, config.Timeouts, "Timeouts should parse each value as a duration")
+	require.Equal(map[string]int{"x": 1, "y": -2}, config.Weights, "Weights should parse each value as an int")
+	require.Equal(&map[int]uint16{1: 80, 2: 443}, config.Ports, "Ports should parse the keys as ints")
+	require.Equal(map[string]string{}, config.Empty, "Empty should be an empty map")
+}
+
+func TestMapWithDecoder(t *testing.T) {
+	type Config struct {
+		Names map[string]upper `env:"NAMES"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NAMES": "a=alice,b=bob",
+	})
+	p.RegisterDecoder(reflect.TypeOf(upper("")), func(raw string) (interface{}, error) {
+		return upper(strings.ToUpper(raw)), nil
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]upper{"a": "ALICE", "b": "BOB"}, config.Names, "each value should use the decoder")
+}
+
+func TestMapInvalidValue(t *testing.T) {
+	type Config struct {
+		Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TIMEOUTS": "a=1s,b=soon",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("TIMEOUTS[b]", specificErr.Key, "the error should identify the entry")
+	require.Equal("soon", specificErr.Value, "the error should contain the value of the entry")
+}
+
+func TestMapInvalidEntry(t *testing.T) {
+	type Config struct {
+		Weights map[string]int `env:"WEIGHTS"`
+	}
+
+	p := mapToParser(map[string]string{
+		"WEIGHTS": "x=1,y",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("map", specificErr.Type, "the error should be for the map")
+}
+
+func TestMapExtendedBools(t *testing.T) {
+	type Config struct {
+		Features map[string]bool `env:"FEATURES"`
+	}
+
+	p := mapToParser(map[string]string{
+		"FEATURES": "a=yes,b=off",
+	})
+	p.ExtendedBools = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]bool{"a": true, "b": false}, config.Features, "each value should accept the extended bools")
+}
+
+func TestMapCoerceFloatToInt(t *testing.T) {
+	type Config struct {
+		Ports map[int]int `env:"PORTS"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PORTS": "1.0=80.0",
+	})
+	p.CoerceFloatToInt = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[int]int{1: 80}, config.Ports, "the keys and values should be coerced")
+}
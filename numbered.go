@@ -0,0 +1,94 @@
+package libconfig
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+)
+
+// numberedName returns the name of the variable for the element at index i of a
+// slice tagged with numbered, e.g. HOSTS_1
+func numberedName(name string, i int) string {
+	return name + "_" + strconv.Itoa(i)
+}
+
+// retrieveNumbered populates a slice tagged with numbered from the variables NAME_1,
+// NAME_2, and so on, or from NAME_0 if the tag has numbered=0. Each value is decoded
+// as an element, and the slice ends at the first number that is not found, even if
+// later ones exist. If none are found, the field is treated like any other variable
+// that is not found.
+func (p *Parser) retrieveNumbered(state *getState, v reflect.Value, tag tagData) error {
+	slice := reflect.MakeSlice(v.Type(), 0, 0)
+
+	for i := tag.NumberFrom; ; i++ {
+		name := numberedName(tag.Name, i)
+
+		value, found, err := p.lookupVar(state.ctx, name)
+		if err != nil {
+			return err
+		}
+		if state.found != nil {
+			state.found[name] = found
+		}
+		if !found {
+			break
+		}
+
+		elemTag := tag
+		elemTag.Name = name
+
+		if tag.Expand {
+			value, err = p.expand(state.ctx, elemTag, value)
+			if err != nil {
+				return err
+			}
+		}
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		err = p.assign(state, elem, elemTag, value)
+		if err != nil {
+			return err
+		}
+
+		slice = reflect.Append(slice, elem)
+	}
+
+	if slice.Len() == 0 {
+		if !tag.Optional {
+			return NewErrVarNotFound(numberedName(tag.Name, tag.NumberFrom))
+		}
+
+		if v.IsZero() {
+			return nil
+		}
+
+		return validate(v, tag)
+	}
+
+	v.Set(slice)
+	state.resolved++
+
+	err := validate(v, tag)
+	if err != nil {
+		return err
+	}
+
+	p.report(state, tag.Name, tag.Name, SourceLookup)
+
+	return nil
+}
+
+// numberedNames adds the names of the variables consumed by a slice tagged with
+// numbered to the set, following the same rules as retrieveNumbered
+func (p *Parser) numberedNames(tag tagData, set map[string]bool) error {
+	for i := tag.NumberFrom; ; i++ {
+		name := numberedName(tag.Name, i)
+
+		_, found, err := p.lookupVar(context.Background(), name)
+		if err != nil || !found {
+			return err
+		}
+
+		set[name] = true
+	}
+}
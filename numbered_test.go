@@ -0,0 +1,128 @@
+package libconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestNumbered(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,numbered"`
+		Ports []int    `env:"PORTS,numbered=0"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOSTS_1": "a.example.com",
+		"HOSTS_2": "b.example.com",
+		"HOSTS_3": "c.example.com",
+		"HOSTS_5": "e.example.com",
+		"PORTS_0": "80",
+		"PORTS_1": "443",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]string{"a.example.com", "b.example.com", "c.example.com"}, config.Hosts, "Hosts should stop at the gap")
+	require.Equal([]int{80, 443}, config.Ports, "Ports should start at 0")
+}
+
+func TestNumberedNoneFound(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,numbered"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOSTS_0": "a.example.com",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrVarNotFound("HOSTS_1")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because numbering starts at 1")
+}
+
+func TestNumberedOptional(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,numbered,optional"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{Hosts: []string{"localhost"}}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]string{"localhost"}, config.Hosts, "Hosts should keep its default")
+}
+
+func TestNumberedBadElement(t *testing.T) {
+	type Config struct {
+		Ports []int `env:"PORTS,numbered"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PORTS_1": "80",
+		"PORTS_2": "http",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("PORTS_2", specificErr.Key, "the error should be for the variable of the element")
+}
+
+func TestNumberedUnusedVars(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,numbered"`
+	}
+
+	p := mapToParser(map[string]string{
+		"HOSTS_1": "a.example.com",
+		"HOSTS_2": "b.example.com",
+		"HOSTS_4": "d.example.com",
+	})
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"HOSTS_4"}, unused, "the variable after the gap should be unused")
+}
+
+func TestNumberedInvalidType(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST,numbered"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("HOST,numbered", "numbered")
+
+	require := require.New(t)
+	require.Equal(expected, err, "numbered should only apply to slices")
+}
+
+func TestNumberedWithJSON(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,numbered,json"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("HOSTS,numbered,json", "numbered")
+
+	require := require.New(t)
+	require.Equal(expected, err, "numbered should not be combined with json")
+}
@@ -0,0 +1,63 @@
+//go:build 386 || arm || mips || mipsle
+
+package libconfig_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestIntOverflow32Bit(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "2147483648",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Int, "VAR_A", "2147483648")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse \"2147483648\" as a 32-bit int")
+}
+
+func TestIntMin32Bit(t *testing.T) {
+	type Config struct {
+		VarA int `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "-2147483649",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Int, "VAR_A", "-2147483649")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse \"-2147483649\" as a 32-bit int")
+}
+
+func TestUintOverflow32Bit(t *testing.T) {
+	type Config struct {
+		VarA uint `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "4294967296",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := libconfig.NewErrOverflow(reflect.Uint, "VAR_A", "4294967296")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail to parse \"4294967296\" as a 32-bit uint")
+}
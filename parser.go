@@ -1,79 +1,515 @@
 package libconfig
 
 import (
-	"encoding/base64"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"os"
 	"reflect"
+	"strings"
+)
+
+// Source identifies where the value of a field came from
+type Source string
+
+const (
+	// SourceLookup is a value found by the lookup function
+	SourceLookup Source = "lookup"
+
+	// SourceAlt is a value found by the lookup function under an alternate name
+	SourceAlt Source = "alt"
+
+	// SourceDefaultFn is a value provided by the Parser's DefaultFn
+	SourceDefaultFn Source = "defaultfn"
+
+	// SourceDefault is the default given in the tag
+	SourceDefault Source = "default"
+
+	// SourceLazyDefault is a value computed by a function registered with
+	// RegisterLazyDefault
+	SourceLazyDefault Source = "lazydefault"
 )
 
 // Parser provides the core logic for libconfig.
 // Typically, you will just use libconfig.Get, which uses a singleton
 type Parser struct {
+	// Tag is the name of the struct tag that holds the variable names, e.g. "env"
 	Tag string
 
+	// Tags, if set, is used instead of Tag. The first of these tags that is present on
+	// a field is used, e.g. []string{"env", "envconfig"} to migrate between libraries.
+	Tags []string
+
+	// Prefix, if set, is prepended to the name of every variable
+	Prefix string
+
 	// LookupFn enables the code to be thoroughly testable without relying on the
 	// actual environment used during testing
 	LookupFn func(key string) (string, bool)
+
+	// LookupFn2, if set, is used instead of LookupFn and can report a failure, e.g. a
+	// network error from a secrets backend, which is wrapped in ErrLookupFailed rather
+	// than being mistaken for a missing variable
+	LookupFn2 func(key string) (value string, found bool, err error)
+
+	// LookupCtxFn, if set, is used instead of LookupFn2 and LookupFn. It receives the
+	// context given to GetContext, so a slow lookup, e.g. from a remote secrets store,
+	// can be interrupted, and any error it returns is wrapped in ErrLookupFailed.
+	LookupCtxFn func(ctx context.Context, key string) (string, bool, error)
+
+	// DefaultFn, if set, provides baseline values for variables that the lookup function
+	// does not find, e.g. from a config server. It takes precedence over any default
+	// given in the tag.
+	DefaultFn func(key string) (string, bool)
+
+	// AliasFn, if set, maps the name of a variable, including any Prefix, to the name
+	// that is passed to the lookup function, e.g. to add a platform's own prefix. Errors
+	// and the names passed to other callbacks are the names before mapping.
+	AliasFn func(name string) string
+
+	// OnSet, if set, is called after each field is set with the name of the variable and
+	// the source of its value, e.g. to log which fields fell back to a default. A value
+	// found under an alternate name is reported with that name and SourceAlt.
+	OnSet func(name string, source Source)
+
+	// OnDeprecated, if set, is called with the name of the variable whenever a field
+	// tagged with deprecated is found, e.g. to warn that it is being phased out. If it
+	// is nil, the warning goes to the Logger, if any. The value is parsed as usual.
+	OnDeprecated func(name string)
+
+	// Logger, if set, receives debug messages for each field that Get considers,
+	// saying whether its variable was found, where its value came from, and how the
+	// value is decoded, with secrets redacted
+	Logger Logger
+
+	// EnumFn optionally lists the names of all available variables, which allows
+	// UnusedVars to find variables that are not consumed by the config. It is required
+	// by maps tagged with prefixmap, which are populated from the variables it lists.
+	EnumFn func() []string
+
+	// Namespace, if set, identifies the component being configured. It is prefixed
+	// onto any error returned by Get and passed to OnResult.
+	Namespace string
+
+	// OnResult, if set, is called once at the end of every Get with the Namespace and
+	// the error that Get returns (or nil), e.g. to count config-load failures
+	OnResult func(namespace string, err error)
+
+	// AllowKDF must be set for fields tagged with kdf, which derive a key from a
+	// passphrase, to be parsed. Key derivation is security-sensitive, so it is opt-in.
+	AllowKDF bool
+
+	// KDFs holds the key derivation functions by the name used in the kdf tag option,
+	// e.g. "scrypt", so that libconfig does not force a choice of crypto library
+	KDFs map[string]KDFFunc
+
+	// CoerceFloatToInt, if set, allows integer fields to be written as floats, e.g. "10.0",
+	// as long as they have no fractional part. By default, integers must be integers.
+	CoerceFloatToInt bool
+
+	// JSONDisallowUnknownFields, if set, causes fields tagged with json to fail to decode
+	// if an object has a key that does not match any field of the destination struct
+	JSONDisallowUnknownFields bool
+
+	// PreserveOnJSONNull, if set, leaves a field tagged with json unchanged if its value
+	// is exactly null, e.g. to keep a default set before Get, rather than letting
+	// json.Unmarshal zero it
+	PreserveOnJSONNull bool
+
+	// JSONUnmarshal, if set, decodes the values of fields tagged with json instead of
+	// encoding/json, e.g. to use a faster library. JSONDisallowUnknownFields does not
+	// apply to it.
+	JSONUnmarshal UnmarshalFunc
+
+	// ExtendedBools, if set, allows booleans to be written as yes/no, on/off, or
+	// enabled/disabled, in any case, in addition to the values accepted by
+	// strconv.ParseBool
+	ExtendedBools bool
+
+	// AutoName, if set, allows the name to be omitted from a tag, e.g. `env:""` or
+	// `env:",optional"`, in which case it is derived from the name of the field using
+	// NameFunc. A prefix derived this way ends in an underscore.
+	AutoName bool
+
+	// NameFunc derives the name of a variable from the name of a field if AutoName is
+	// set. If nil, the name is converted to UPPER_SNAKE_CASE, e.g. MaxConns becomes
+	// MAX_CONNS.
+	NameFunc func(fieldName string) string
+
+	// IgnoreUnknownOptions, if set, skips unknown tag options rather than returning
+	// ErrInvalidTagOption, e.g. for structs shared with other libraries that add their
+	// own options to the same tag. Beware that misspelled options are ignored too.
+	IgnoreUnknownOptions bool
+
+	// AllowNestedTags, if set, suppresses ErrNestedTags for a tagged struct that contains
+	// tagged fields, e.g. because the tags are meant for a different tool. The struct is
+	// populated from its own variable and the tags of its fields are ignored.
+	AllowNestedTags bool
+
+	// ErrorOnPreset, if set, causes Get to return ErrAlreadySet for a required field that
+	// already has a non-zero value, e.g. because Get was accidentally called twice on
+	// the same struct. Optional fields may still hold defaults, but GetWithDefaults
+	// cannot set required ones.
+	ErrorOnPreset bool
+
+	// CollectErrors, if set, causes Get to continue past fields that fail and return an
+	// ErrMultiple holding every error, in the order of the fields: declaration order,
+	// depth-first through nested structs and the elements of indexed slices. A
+	// cancelled context still stops Get immediately.
+	CollectErrors bool
+
+	// ResolveRefs, if set, treats a value that is exactly $OTHER_VAR as a reference to
+	// the variable OTHER_VAR, whose value is used instead, e.g. so DATABASE_URL can
+	// mirror DB_URL. Unlike the expand option, only the whole value is replaced and only
+	// one level deep. A reference to a missing variable is an ErrVarNotFound for that
+	// variable, unless the field is optional, in which case it is treated as not found.
+	ResolveRefs bool
+
+	// LenientBase64, if set, accepts values tagged with base64 that are unpadded or
+	// URL-safe, as some producers emit, if they are not valid standard base64
+	LenientBase64 bool
+
+	// Stdin is read for fields tagged with stdin whose value is "-". If nil, os.Stdin
+	// is used.
+	Stdin io.Reader
+
+	// decoders holds the custom decoders registered by type
+	decoders map[reflect.Type]DecoderCtxFunc
+
+	// lazyDefaults holds the functions registered with RegisterLazyDefault by name
+	lazyDefaults map[string]LazyDefaultFunc
+
+	// c caches compiled patterns
+	c *cache
 }
 
 // Get retrieves the configuration for the given struct by gathering values
 // from the given LookupFn
 func (p *Parser) Get(config interface{}) error {
+	return p.GetContext(context.Background(), config)
+}
+
+// GetContext is like Get, but passes the context to LookupCtxFn and to any
+// context-aware custom decoders. If the context is done, GetContext returns an
+// ErrLookupFailed that wraps the context's error before looking up the next field.
+func (p *Parser) GetContext(ctx context.Context, config interface{}) error {
+	return p.result(p.get(newGetState(ctx), config))
+}
+
+// GetWithMeta is like Get, but also returns whether each variable was found, keyed by
+// name, so that a variable that is explicitly set to "" can be told apart from one that
+// is missing. If Get fails, the variables consulted up to that point are returned.
+func (p *Parser) GetWithMeta(config interface{}) (map[string]bool, error) {
+	state := newGetState(context.Background())
+	state.found = map[string]bool{}
+
+	err := p.result(p.get(state, config))
+
+	return state.found, err
+}
+
+// Resolution describes where the value of a field came from
+type Resolution struct {
+	// Name is the name of the variable that provided the value, which is an alternate
+	// name if the value was found under one
+	Name string
+
+	// Source is the source of the value, e.g. SourceAlt for an alternate name
+	Source Source
+}
+
+// GetResolved is like Get, but also reports where the value of each field came from,
+// keyed by the primary name of its variable, in order of priority: the lookup
+// function, then alternate names, then the DefaultFn, the default given in the tag,
+// and a lazy default. Optional fields that are not set are omitted. If Get fails, the
+// fields resolved up to that point are returned.
+func (p *Parser) GetResolved(config interface{}) (map[string]Resolution, error) {
+	state := newGetState(context.Background())
+	state.resolutions = map[string]Resolution{}
+
+	err := p.result(p.get(state, config))
+
+	return state.resolutions, err
+}
+
+// GetWithAnyFound is like Get, but also returns whether at least one variable was
+// found by the lookup function, e.g. to detect an environment that has not been
+// configured at all. Values from the DefaultFn or tag defaults do not count.
+func (p *Parser) GetWithAnyFound(config interface{}) (bool, error) {
+	found, err := p.GetWithMeta(config)
+
+	for _, ok := range found {
+		if ok {
+			return true, err
+		}
+	}
+
+	return false, err
+}
+
+// GetMap looks up each of the names, with the Parser's Prefix, and returns the values
+// keyed by the names as given, for dynamic keys that do not fit a struct. Every name is
+// required, so ErrVarNotFound is returned for the first one that is not found.
+func (p *Parser) GetMap(names []string) (map[string]string, error) {
+	values, err := p.getMap(names, false)
+	return values, p.result(err)
+}
+
+// GetMapOptional is like GetMap, but omits the names that are not found
+func (p *Parser) GetMapOptional(names []string) (map[string]string, error) {
+	values, err := p.getMap(names, true)
+	return values, p.result(err)
+}
+
+// getMap does the work of GetMap and GetMapOptional
+func (p *Parser) getMap(names []string, optional bool) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+
+	for _, name := range names {
+		value, found, err := p.lookupVar(context.Background(), p.Prefix+name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			if optional {
+				continue
+			}
+			return nil, NewErrVarNotFound(p.Prefix + name)
+		}
+
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+// result applies the Namespace to the error returned by Get and reports it to OnResult
+func (p *Parser) result(err error) error {
+	if err != nil && p.Namespace != "" {
+		err = NewErrNamespace(p.Namespace, err)
+	}
+
+	if p.OnResult != nil {
+		p.OnResult(p.Namespace, err)
+	}
+
+	return err
+}
+
+// ParseValue is like Get, but populates a reflect.Value, which must be either an
+// addressable struct or a non-nil pointer to a struct, for frameworks that already
+// hold one
+func (p *Parser) ParseValue(v reflect.Value) error {
+	return p.result(p.parseValue(newGetState(context.Background()), v))
+}
+
+// parseValue does the work of ParseValue
+func (p *Parser) parseValue(state *getState, v reflect.Value) error {
+	if !v.IsValid() {
+		return NewErrInvalidConfigType(nil)
+	}
+
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			return NewErrInvalidConfigType(v.Type())
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return NewErrInvalidConfigType(v.Type())
+	}
+	if !v.CanSet() {
+		return NewErrNotAddressable(v.Type())
+	}
+
+	_, err := p.parse(state, v, p.Prefix, "")
+
+	return state.collected(err)
+}
+
+// get does the work of GetContext
+func (p *Parser) get(state *getState, config interface{}) error {
 	v := reflect.ValueOf(config)
 	if t := v.Type(); !(t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct) {
 		return NewErrInvalidConfigType(t)
 	}
 
-	_, err := p.parse(v.Elem())
+	// Execute the plan if the type has been prepared, rather than walking the struct
+	if plan := p.prepared(v.Type().Elem()); plan != nil {
+		return state.collected(p.execute(state, plan, v.Elem()))
+	}
 
-	return err
+	_, err := p.parse(state, v.Elem(), p.Prefix, "")
+
+	return state.collected(err)
+}
+
+// GetWithDefaults copies the defaults struct into config and then populates config
+// from the LookupFn, so any optional variable that is not found retains its default.
+// The defaults may be a struct or a pointer to a struct, but either way it must be
+// of the same struct type as config.
+func (p *Parser) GetWithDefaults(config interface{}, defaults interface{}) error {
+	v := reflect.ValueOf(config)
+	if t := v.Type(); !(t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct) {
+		return p.result(NewErrInvalidConfigType(t))
+	}
+
+	d := reflect.ValueOf(defaults)
+	if d.Kind() == reflect.Ptr && !d.IsNil() {
+		d = d.Elem()
+	}
+	if !d.IsValid() || d.Type() != v.Type().Elem() {
+		return p.result(NewErrDefaultsTypeMismatch(v.Type().Elem(), reflect.TypeOf(defaults)))
+	}
+
+	// The config must not share pointers, maps, or slices with the defaults, or Get
+	// would change the defaults too
+	v.Elem().Set(deepCopy(d))
+
+	return p.Get(config)
+}
+
+// deepCopy returns a copy of v that shares no pointers, maps, or slices with it.
+// Unexported fields are copied as they are, since they cannot be set and are never
+// populated.
+func deepCopy(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			c.Set(reflect.New(v.Type().Elem()))
+			c.Elem().Set(deepCopy(v.Elem()))
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			c.Set(deepCopy(v.Elem()))
+		}
+	case reflect.Struct:
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				c.Index(i).Set(deepCopy(v.Index(i)))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+	default:
+		c.Set(v)
+	}
+
+	return c
 }
 
 // parse the given interface, looking for our tag, which indicates
-// that the field can be populated by an environment variable
-func (p *Parser) parse(config reflect.Value) (bool, error) {
+// that the field can be populated by an environment variable. The prefix is
+// prepended to the name of every tagged field.
+//
+// Nested structs, whether embedded or not, are handled as follows:
+//   - An untagged struct is parsed, and its tagged fields belong to the parent.
+//   - A struct tagged with prefix is parsed, and its tag name is prepended to the
+//     names of its tagged fields, in addition to any prefix inherited from the parent.
+//   - Any other tagged struct is populated from its own variable (typically as json)
+//     and must not contain any tagged fields, otherwise ErrNestedTags is returned.
+//     If the Parser allows nested tags, the tagged fields are ignored instead.
+//   - A nil pointer to a struct is allocated, but reset to nil if none of the fields of
+//     the struct are set, e.g. because they are all optional and missing.
+//
+// A slice of structs tagged with indexed is populated by parseIndexed.
+//
+// The path is the path of the struct from the config, e.g. "Database.", which is empty
+// for the config itself. Errors for the fields of nested structs are wrapped in an
+// ErrField with the full path of the field.
+func (p *Parser) parse(state *getState, config reflect.Value, prefix, path string) (bool, error) {
 	var tagFound bool
 
-	// Look at each field of the struct
-	t := config.Type()
+	// Look at each field of the struct, stopping at the first tag that fails to parse
+	fields, tagErr := p.fields(config.Type(), prefix)
 
-	for i := 0; i < t.NumField(); i++ {
-		// Get the struct field tag data
-		field := t.Field(i)
-		value := config.Field(i)
-		tag, err := parseTag(field, p.Tag)
-		if err != nil {
-			return tagFound, err
-		}
+	for _, f := range fields {
+		field := f.Field
+		tag := f.Tag
+		value := config.Field(f.Index)
 
 		// Parse tagged fields
-		if tag.Tagged {
+		if tag.Indexed {
+			tagFound = true
+
+			// Get each element of the slice from its own set of variables
+			err := p.parseIndexed(state, value, tag, path+field.Name)
+			if err != nil && !p.collect(state, err) {
+				return tagFound, err
+			}
+		} else if tag.Tagged && !tag.Prefix {
 			tagFound = true
 
 			// Get the value from the LookupFn
-			err = p.retrieve(value, tag)
-			if err != nil {
+			err := wrapField(path, field.Name, p.retrieve(state, value, tag))
+			if err != nil && !p.collect(state, err) {
 				return tagFound, err
 			}
 		}
 
-		// If the field is a struct or pointer-to-struct, parse it
-		if field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+		// If the field is a struct or pointer-to-struct, parse it, unless it is tagged
+		// and nested tags are allowed, in which case they are ignored, or it is tagged
+		// with kv, in which case they are keys within its value
+		if f.IsStruct && !(tag.Tagged && !tag.Prefix && p.AllowNestedTags) && !tag.KV {
 			// If the field is a pointer-to-struct, get the struct, not the pointer
+			var allocated reflect.Value
 			if field.Type.Kind() == reflect.Ptr {
 				// If the pointer is nil, allocate memory first
 				if value.IsNil() {
 					value.Set(reflect.New(field.Type.Elem()))
+					allocated = value
 				}
 				value = value.Elem()
 			}
 
-			found, err := p.parse(value)
+			// A prefix struct passes its (already prefixed) name down to its fields
+			nestedPrefix := prefix
+			if tag.Prefix {
+				nestedPrefix = tag.Name
+			}
+
+			if p.Logger != nil {
+				p.Logger.Debugf("libconfig: parsing struct [%s] with prefix [%s]", path+field.Name, nestedPrefix)
+			}
+
+			resolved := state.resolved
+			found, err := p.parse(state, value, nestedPrefix, path+field.Name+".")
+
+			// Keep the pointer nil if none of the fields of the struct were set
+			if allocated.IsValid() && state.resolved == resolved {
+				allocated.Set(reflect.Zero(field.Type))
+			}
 
 			// First ensure that a tagged struct contains no tagged members
-			if tag.Tagged && found {
+			if tag.Tagged && !tag.Prefix && found {
 				return tagFound, NewErrNestedTags(field.Name, tag.Name)
 			}
+			tagFound = tagFound || found
 
 			// Handle any errors second
 			if err != nil {
@@ -82,61 +518,503 @@ func (p *Parser) parse(config reflect.Value) (bool, error) {
 		}
 	}
 
-	return tagFound, nil
+	return tagFound, tagErr
 }
 
-// retrieve gets the value for the tag from the lookup function, handling any
-// necessary decoding, such as base64.
-func (p *Parser) retrieve(v reflect.Value, tag tagData) error {
-	var bytes []byte
+// collect records the error for a field if the Parser collects errors, returning false
+// if parsing must stop instead, e.g. because the context is done
+func (p *Parser) collect(state *getState, err error) bool {
+	if !p.CollectErrors || state.ctx.Err() != nil {
+		return false
+	}
+
+	state.errs = append(state.errs, err)
+	return true
+}
+
+// wrapField wraps the error for the field in an ErrField, unless the field belongs to
+// the config itself, i.e. the path of its struct is empty
+func wrapField(path, name string, err error) error {
+	if err == nil || path == "" {
+		return err
+	}
+
+	return NewErrField(path+name, err)
+}
+
+// tagNames returns the names of the struct tags to look for, in order of precedence
+func (p *Parser) tagNames() []string {
+	if len(p.Tags) > 0 {
+		return p.Tags
+	}
+
+	return []string{p.Tag}
+}
+
+// parseTag parses the struct field tag, deriving the name if necessary and applying the
+// prefix to it
+func (p *Parser) parseTag(field reflect.StructField, prefix string) (tagData, error) {
+	tag, err := p.parseTagOptions(field, prefix)
+	if tag.AutoNamed {
+		tag.Name = p.derivedName(field, tag, prefix)
+	}
+
+	return tag, err
+}
+
+// parseTagOptions is like parseTag, but leaves the name empty if it is to be derived
+// from the name of the field, which depends on the NameFunc, so that the result can be
+// cached
+func (p *Parser) parseTagOptions(field reflect.StructField, prefix string) (tagData, error) {
+	tag, err := parseTag(field, p.tagNames(), tagRules{
+		AutoName:             p.AutoName,
+		IgnoreUnknownOptions: p.IgnoreUnknownOptions,
+	})
+	if err != nil || !tag.Tagged {
+		return tag, err
+	}
+
+	if tag.Name == "" {
+		tag.AutoNamed = true
+	} else {
+		tag.Name = prefix + tag.Name
+	}
+	for i, alt := range tag.Alt {
+		tag.Alt[i] = prefix + alt
+	}
+	if tag.Salt != "" {
+		tag.Salt = prefix + tag.Salt
+	}
+
+	if tag.Pattern != "" {
+		tag.Regexp, err = p.compile(tag.Pattern)
+	}
+
+	return tag, err
+}
+
+// derivedName returns the name for a tag whose name was omitted, derived from the name
+// of the field with the NameFunc and then prefixed
+func (p *Parser) derivedName(field reflect.StructField, tag tagData, prefix string) string {
+	nameFn := p.NameFunc
+	if nameFn == nil {
+		nameFn = upperSnake
+	}
+
+	name := nameFn(field.Name)
+	if tag.Prefix {
+		name += "_"
+	}
+
+	return prefix + name
+}
+
+// alias returns the name that is passed to the lookup function for the variable,
+// mapped by the AliasFn if set
+func (p *Parser) alias(name string) string {
+	if p.AliasFn == nil {
+		return name
+	}
+
+	return p.AliasFn(name)
+}
+
+// lookupVar looks up a single variable using the first of LookupCtxFn, LookupFn2, and
+// LookupFn that is set, after mapping its name with the AliasFn
+func (p *Parser) lookupVar(ctx context.Context, name string) (string, bool, error) {
+	var value string
+	var found bool
 	var err error
 
-	value, found := p.LookupFn(tag.Name)
+	switch actual := p.alias(name); {
+	case p.LookupCtxFn != nil:
+		value, found, err = p.LookupCtxFn(ctx, actual)
+	case p.LookupFn2 != nil:
+		value, found, err = p.LookupFn2(actual)
+	default:
+		value, found = p.LookupFn(actual)
+	}
+
+	if err != nil {
+		return "", false, NewErrLookupFailed(name, err)
+	}
+
+	return value, found, nil
+}
+
+// lookup looks up the variable named in the tag and then, if it is not found, each
+// of the alternate names in order. It returns the name that was found, or the primary
+// name if none were found.
+func (p *Parser) lookup(ctx context.Context, tag tagData) (string, string, bool, error) {
+	for _, name := range append([]string{tag.Name}, tag.Alt...) {
+		value, found, err := p.lookupVar(ctx, name)
+		if err != nil {
+			return name, "", false, err
+		}
+		if found && !(tag.EmptyUnset && value == "") {
+			return name, value, true, nil
+		}
+	}
+
+	return tag.Name, "", false, nil
+}
+
+// retrieve gets the value for the tag like retrieveValue, adding the message from the
+// tag to any error
+func (p *Parser) retrieve(state *getState, v reflect.Value, tag tagData) error {
+	err := p.retrieveValue(state, v, tag)
+	if err != nil && tag.Message != "" {
+		return NewErrWithMessage(tag.Name, tag.Message, err)
+	}
+
+	return err
+}
+
+// retrieveValue gets the value for the tag from the lookup function, sets it and then
+// validates the result. If the variable is not found, the value from the DefaultFn or
+// the default given in the tag is used instead, or, if the variable is optional, the
+// current value is validated.
+func (p *Parser) retrieveValue(state *getState, v reflect.Value, tag tagData) error {
+	// Stop early if the context is done, e.g. because a previous lookup was slow
+	if err := state.ctx.Err(); err != nil {
+		return NewErrLookupFailed(tag.Name, err)
+	}
+
+	if p.ErrorOnPreset && !tag.Optional && !v.IsZero() {
+		return NewErrAlreadySet(tag.Name)
+	}
+
+	// A numbered slice is populated from a variable per element
+	if tag.Numbered {
+		return p.retrieveNumbered(state, v, tag)
+	}
+
+	// As is a map tagged with prefixmap, from every variable with the prefix
+	if tag.PrefixMap {
+		return p.retrievePrefixMap(state, v, tag)
+	}
+
+	name, value, found, err := p.lookup(state.ctx, tag)
+	if err != nil {
+		return err
+	}
+	if state.found != nil {
+		state.found[tag.Name] = found
+	}
+	if p.Logger != nil {
+		p.Logger.Debugf("libconfig: looked up [%s] as [%s], found: %t", tag.Name, name, found)
+	}
+	if found && tag.Deprecated {
+		p.deprecated(name)
+	}
+
+	// With presence, the variable is a flag that is true if it is found at all
+	if tag.Presence {
+		if !found {
+			return nil
+		}
+		value = "true"
+	}
+
+	if found && p.ResolveRefs {
+		var ref string
+		value, ref, found, err = p.resolveRef(state.ctx, value)
+		if err != nil {
+			return err
+		}
+		if !found && !tag.Optional {
+			return NewErrVarNotFound(ref)
+		}
+		if ref != "" && p.Logger != nil {
+			p.Logger.Debugf("libconfig: resolved reference to [%s], found: %t", ref, found)
+		}
+	}
+
+	// Errors refer to the variable that was actually found
+	key := tag.Name
+	tag.Name = name
+	source := SourceLookup
+	if name != key {
+		source = SourceAlt
+	}
+
+	// Then fall back to the DefaultFn and then the default given in the tag, both of
+	// which are decoded exactly like a value that was found
+	if !found && p.DefaultFn != nil {
+		value, found = p.DefaultFn(tag.Name)
+		source = SourceDefaultFn
+	}
+	if !found && tag.HasDefault {
+		value, found = tag.Default, true
+		source = SourceDefault
+	}
+	if !found && !tag.Optional {
+		value, found, err = p.lazyDefault(tag.Name)
+		if err != nil {
+			return err
+		}
+		source = SourceLazyDefault
+	}
+
 	if !found {
 		if !tag.Optional {
 			return NewErrVarNotFound(tag.Name)
 		}
 
-		return nil
+		if p.Logger != nil {
+			p.Logger.Debugf("libconfig: [%s] is optional and not found, keeping its current value", tag.Name)
+		}
+
+		if v.IsZero() {
+			return nil
+		}
+
+		return validate(v, tag)
+	}
+
+	if p.Logger != nil {
+		p.Logger.Debugf("libconfig: setting [%s] from %s to [%s], decoding with %v", tag.Name, source, logValue(tag, value), tag.decodings())
+	}
+
+	if tag.Expand {
+		value, err = p.expand(state.ctx, tag, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// By convention, "-" means read the value from stdin
+	if tag.Stdin && value == "-" {
+		stdin := p.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+
+		bytes, err := io.ReadAll(stdin)
+		if err != nil {
+			return NewErrDecodeFailure(err, tag.Name, value, "stdin")
+		}
+		value = string(bytes)
+	}
+
+	// The value is the path of a file holding the real value, e.g. a secret mounted
+	// by Docker or Kubernetes. Raw bytes, e.g. a binary key, are kept exactly.
+	if tag.FromFile {
+		raw := isBytes(v.Type()) && !tag.Base64 && !tag.Hex && !isHardwareAddr(derefType(v.Type()), tag)
+		value, err = readValueFile(tag.Name, value, !raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A JSON null keeps the current value, which is validated like an optional field
+	// that is not found
+	if p.PreserveOnJSONNull && tag.JSON && value == "null" {
+		if v.IsZero() {
+			return nil
+		}
+
+		return validate(v, tag)
+	}
+
+	err = p.assign(state, v, tag, value)
+	if err != nil {
+		return err
+	}
+	state.resolved++
+
+	// A value decoded as a whole may check its own invariants
+	if tag.JSON || tag.Unmarshaler != "" {
+		err = validateDecoded(v, tag.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tag.Lower || tag.Upper {
+		changeCase(v, tag)
+	}
+
+	err = validate(v, tag)
+	if err != nil {
+		return err
+	}
+
+	p.report(state, key, tag.Name, source)
+
+	return nil
+}
+
+// deprecated warns that the deprecated variable was found, with OnDeprecated or, if it
+// is nil, the Logger
+func (p *Parser) deprecated(name string) {
+	switch {
+	case p.OnDeprecated != nil:
+		p.OnDeprecated(name)
+	case p.Logger != nil:
+		p.Logger.Debugf("libconfig: [%s] is deprecated", name)
+	}
+}
+
+// report records that the value of the variable named key was set from the source,
+// found under the given name, and passes it to the OnSet callback
+func (p *Parser) report(state *getState, key, name string, source Source) {
+	if state.resolutions != nil {
+		state.resolutions[key] = Resolution{Name: name, Source: source}
+	}
+
+	if p.OnSet != nil {
+		p.OnSet(name, source)
+	}
+}
+
+// changeCase lowercases or uppercases the string v, or the string it points to, as
+// specified by the tag
+func changeCase(v reflect.Value, tag tagData) {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if tag.Lower {
+		v.SetString(strings.ToLower(v.String()))
+	} else {
+		v.SetString(strings.ToUpper(v.String()))
+	}
+}
+
+// readValueFile returns the contents of the file at the path, optionally without the
+// trailing newline that editors and `echo` usually add
+func readValueFile(key, path string, trim bool) (string, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", NewErrFileRead(key, path, err)
+	}
+	if !trim {
+		return string(bytes), nil
+	}
+
+	value := strings.TrimSuffix(string(bytes), "\n")
+	value = strings.TrimSuffix(value, "\r")
+
+	return value, nil
+}
+
+// gunzip decompresses the gzipped data
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// assign sets the value for the tag, handling any necessary decoding, such as base64
+func (p *Parser) assign(state *getState, v reflect.Value, tag tagData, value string) error {
+	var bytes []byte
+	var err error
+
+	// The tag is cached, so the settings of the Parser, which may change between calls,
+	// are applied to each value
+	tag.CoerceFloat = p.CoerceFloatToInt
+	tag.Truthy = p.ExtendedBools
+
+	// A [][]byte tagged with base64 is a comma-separated list of base64 items
+	if tag.Base64 && !tag.JSON && isBytesList(v.Type()) {
+		return parseBase64List(v, tag, value, p.LenientBase64)
 	}
 
 	// Base64-decode if specified
 	if tag.Base64 {
-		bytes, err = base64.StdEncoding.DecodeString(value)
+		bytes, err = decodeBase64(value, p.LenientBase64)
 		if err != nil {
 			return NewErrDecodeFailure(err, tag.Name, value, "base64")
 		}
+	} else if tag.Hex {
+		bytes, err = hex.DecodeString(value)
+		if err != nil {
+			return NewErrDecodeFailure(err, tag.Name, value, "hex")
+		}
 	} else {
 		bytes = []byte(value)
 	}
 
-	// JSON-decode if specified
-	if tag.JSON {
-		if v.Kind() == reflect.Ptr {
-			// If v is a nil pointer, we need to allocate memory
-			if v.IsNil() {
-				v.Set(reflect.New(v.Type().Elem()))
-			}
-		} else {
-			// We need a pointer to the struct for unmarshalling
-			v = v.Addr()
+	// Decompress if specified
+	if tag.Gzip {
+		bytes, err = gunzip(bytes)
+		if err != nil {
+			return NewErrDecodeFailure(err, tag.Name, value, "gzip")
 		}
+	}
 
-		err = json.Unmarshal(bytes, v.Interface())
+	// Derive a key from the passphrase if specified
+	if tag.KDF != "" {
+		bytes, err = p.derive(state.ctx, tag, bytes)
 		if err != nil {
-			return NewErrDecodeFailure(err, tag.Name, value, "json")
+			return err
 		}
+	}
 
-		return nil
+	// Parse the columns into the fields of a struct if specified
+	if tag.PosCSV {
+		return parsePosCSV(v, tag, bytes)
 	}
 
-	if v.Kind() == reflect.Ptr {
-		// v is a Pointer; we need to allocate memory
+	// Parse a single line of CSV into the elements of a slice if specified
+	if tag.CSV {
+		return parseCSVList(v, tag, bytes)
+	}
+
+	// Parse key=value pairs into the fields of a struct if specified
+	if tag.KV {
+		return p.parseKV(state, v, tag, string(bytes))
+	}
+
+	// Keep the raw JSON for a json.RawMessage, after checking that it is valid
+	if tag.JSON && v.Type() == rawMessageType {
+		return setRawMessage(v, tag, value, bytes)
+	}
+
+	// JSON-decode if specified
+	if tag.JSON {
+		fn := json.Unmarshal
+		if p.JSONUnmarshal != nil {
+			fn = p.JSONUnmarshal
+		} else if p.JSONDisallowUnknownFields {
+			fn = unmarshalJSONStrict
+		}
+		return unmarshal(v, tag, value, bytes, fn, "json")
+	}
+
+	// Decode with a registered unmarshaler, e.g. yaml, if specified
+	if tag.Unmarshaler != "" {
+		fn, _ := unmarshaler(tag.Unmarshaler)
+		return unmarshal(v, tag, value, bytes, fn, tag.Unmarshaler)
+	}
+
+	// Use a custom decoder if one is registered for the type
+	if ok, err := p.decode(state.ctx, v, tag, string(bytes)); ok {
+		return err
+	}
+
+	for v.Kind() == reflect.Ptr && v.Type() != regexpType {
+		// v is a Pointer, possibly to another pointer; we need to allocate memory at
+		// each level
 		v.Set(reflect.New(v.Type().Elem()))
 		v = v.Elem()
+
+		if ok, err := p.decode(state.ctx, v, tag, string(bytes)); ok {
+			return err
+		}
+	}
+
+	// Parse a map from key=value pairs
+	if isMap(v) {
+		return p.parseMap(state, v, tag, string(bytes))
 	}
 
-	err = setValue(v, tag.Name, bytes)
+	err = setValue(v, tag, bytes)
 
 	return err
 }
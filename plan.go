@@ -0,0 +1,320 @@
+package libconfig
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// Plan is a precomputed, flattened description of how to populate a struct type. It
+// is the representation shared by the runtime, which can execute it with
+// GetWithPlan, and by code generators, which can emit a specialized Get from it.
+type Plan struct {
+	// Type is the struct type described by the plan
+	Type reflect.Type
+
+	// Steps holds the work to do, in the same order that Get would do it
+	Steps []PlanStep
+
+	// autoNamed is set if any name in the plan is derived from the name of a field,
+	// in which case the plan depends on the NameFunc
+	autoNamed bool
+}
+
+// PlanStep is either a tagged field to populate from a variable (or, for a slice
+// tagged with indexed, from a set of variables per element) or, if Alloc is set, a
+// nil pointer-to-struct to allocate before its fields are populated. The pointer is
+// reset to nil after the steps for its fields if none of them set a value.
+type PlanStep struct {
+	// Index is the sequence of field indexes from the root struct, as used by
+	// reflect.Value.FieldByIndex
+	Index []int
+
+	// Offset is the byte offset of the field within its enclosing struct
+	Offset uintptr
+
+	// Alloc is set if the step only allocates a nil pointer-to-struct
+	Alloc bool
+
+	// Name is the name of the variable, including any prefix
+	Name string
+
+	// Options holds the tag options following the name, e.g. "optional,base64"
+	Options string
+
+	tag   tagData
+	path  string
+	field string
+}
+
+// planKey identifies the plan for a struct type. Beyond the fields, the steps depend
+// on whether nested tags are allowed.
+type planKey struct {
+	fieldsKey
+	AllowNestedTags bool
+}
+
+// planKey returns the key for the plan for the struct type
+func (p *Parser) planKey(t reflect.Type) planKey {
+	return planKey{
+		fieldsKey:       p.fieldsKey(t, p.Prefix),
+		AllowNestedTags: p.AllowNestedTags,
+	}
+}
+
+// PlanFor returns the plan for the struct type, which may be a struct or a pointer to
+// a struct. Plans are cached, so repeated calls for the same type are cheap. Unlike
+// Get, PlanFor detects ErrNestedTags without consulting the LookupFn.
+func (p *Parser) PlanFor(t reflect.Type) (*Plan, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, NewErrInvalidConfigType(t)
+	}
+
+	key := p.planKey(t)
+
+	c := p.cache()
+	c.mu.Lock()
+	plan, ok := c.plans[key]
+	c.mu.Unlock()
+	if ok && p.reusable(plan) {
+		return plan, nil
+	}
+
+	plan = &Plan{Type: t}
+	_, err := p.plan(plan, t, nil, p.Prefix, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.reusable(plan) {
+		c.mu.Lock()
+		c.plans[key] = plan
+		c.mu.Unlock()
+	}
+
+	return plan, nil
+}
+
+// plan appends the steps for the struct type to the plan, following the same rules
+// as parse, and returns true if the struct has any tagged fields
+func (p *Parser) plan(plan *Plan, t reflect.Type, index []int, prefix, path string) (bool, error) {
+	var tagFound bool
+
+	fields, err := p.fields(t, prefix)
+	if err != nil {
+		return tagFound, err
+	}
+
+	for _, f := range fields {
+		field := f.Field
+		tag := f.Tag
+		fieldIndex := append(append([]int{}, index...), f.Index)
+		plan.autoNamed = plan.autoNamed || tag.AutoNamed
+
+		if tag.Tagged && !tag.Prefix {
+			tagFound = true
+
+			value, _ := lookupTag(field, p.tagNames())
+			_, options, _ := strings.Cut(value, ",")
+			plan.Steps = append(plan.Steps, PlanStep{
+				Index:   fieldIndex,
+				Offset:  field.Offset,
+				Name:    tag.Name,
+				Options: options,
+				tag:     tag,
+				path:    path,
+				field:   field.Name,
+			})
+		}
+
+		if f.IsStruct && !(tag.Tagged && !tag.Prefix && p.AllowNestedTags) && !tag.KV {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+				plan.Steps = append(plan.Steps, PlanStep{
+					Index:  fieldIndex,
+					Offset: field.Offset,
+					Alloc:  true,
+				})
+			}
+
+			nestedPrefix := prefix
+			if tag.Prefix {
+				nestedPrefix = tag.Name
+			}
+
+			found, err := p.plan(plan, ft, fieldIndex, nestedPrefix, path+field.Name+".")
+			if tag.Tagged && !tag.Prefix && found {
+				return tagFound, NewErrNestedTags(field.Name, tag.Name)
+			}
+			tagFound = tagFound || found
+
+			if err != nil {
+				return tagFound, err
+			}
+		}
+	}
+
+	return tagFound, nil
+}
+
+// RegisterStruct builds the plan for the struct type, which may be a struct or a
+// pointer to a struct, and keeps it so that every later Get for the type executes the
+// plan rather than walking the struct, with identical results and errors. It fails if
+// PlanFor does, in which case Get walks the struct as usual. The plan only applies
+// while the Parser's tags, prefix, and the settings that affect them are unchanged,
+// and not at all if its names are derived with a NameFunc, which cannot be compared.
+func (p *Parser) RegisterStruct(t reflect.Type) error {
+	plan, err := p.PlanFor(t)
+	if err != nil {
+		return err
+	}
+
+	c := p.cache()
+	c.mu.Lock()
+	c.prepared[p.planKey(plan.Type)] = plan
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Prepare is like RegisterStruct for the type of the config, e.g. to prepare a config
+// once during startup
+func (p *Parser) Prepare(config interface{}) error {
+	return p.RegisterStruct(reflect.TypeOf(config))
+}
+
+// prepared returns the plan registered for the struct type, or nil if there is none
+func (p *Parser) prepared(t reflect.Type) *Plan {
+	key := p.planKey(t)
+
+	c := p.cache()
+	c.mu.Lock()
+	plan := c.prepared[key]
+	c.mu.Unlock()
+
+	if plan == nil || !p.reusable(plan) {
+		return nil
+	}
+
+	return plan
+}
+
+// reusable returns true if the cached plan applies to the Parser, which is not the
+// case if its names are derived with a NameFunc. Plans are only cached with names
+// derived by the default conversion.
+func (p *Parser) reusable(plan *Plan) bool {
+	return !plan.autoNamed || p.NameFunc == nil
+}
+
+// GetWithPlan populates the config, which must be a pointer to the plan's type, by
+// executing the plan rather than walking the struct
+func (p *Parser) GetWithPlan(plan *Plan, config interface{}) error {
+	return p.result(p.getWithPlan(plan, config))
+}
+
+// getWithPlan does the work of GetWithPlan
+func (p *Parser) getWithPlan(plan *Plan, config interface{}) error {
+	v := reflect.ValueOf(config)
+	if t := v.Type(); !(t.Kind() == reflect.Ptr && t.Elem() == plan.Type) {
+		return NewErrInvalidConfigType(t)
+	}
+
+	state := newGetState(context.Background())
+	return state.collected(p.execute(state, plan, v.Elem()))
+}
+
+// execute populates the struct by executing the plan, returning the first error unless
+// the Parser collects errors
+func (p *Parser) execute(state *getState, plan *Plan, root reflect.Value) error {
+
+	// allocated holds the pointers allocated by enclosing Alloc steps, innermost last,
+	// each of which is reset to nil if none of the fields of its struct are set
+	var allocated []allocation
+	defer func() {
+		releaseAllocations(state, allocated, nil)
+	}()
+
+	for _, step := range plan.Steps {
+		allocated = releaseAllocations(state, allocated, step.Index)
+		value := fieldByIndex(root, step.Index)
+
+		if step.Alloc {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+				allocated = append(allocated, allocation{step.Index, value, state.resolved})
+			}
+			continue
+		}
+
+		var err error
+		if step.tag.Indexed {
+			err = p.parseIndexed(state, value, step.tag, step.path+step.field)
+		} else {
+			err = wrapField(step.path, step.field, p.retrieve(state, value, step.tag))
+		}
+		if err != nil && !p.collect(state, err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allocation is a pointer allocated by an Alloc step and the number of fields that
+// had been resolved at the time
+type allocation struct {
+	index    []int
+	value    reflect.Value
+	resolved int
+}
+
+// releaseAllocations removes the allocations that do not enclose the field at the
+// index, resetting each to nil if none of the fields of its struct were set, and
+// returns those that remain
+func releaseAllocations(state *getState, allocated []allocation, index []int) []allocation {
+	for len(allocated) > 0 {
+		last := allocated[len(allocated)-1]
+		if len(index) > len(last.index) && equalIndex(index[:len(last.index)], last.index) {
+			break
+		}
+
+		if state.resolved == last.resolved {
+			last.value.Set(reflect.Zero(last.value.Type()))
+		}
+		allocated = allocated[:len(allocated)-1]
+	}
+
+	return allocated
+}
+
+// equalIndex returns true if the field indexes are equal
+func equalIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fieldByIndex returns the nested field of v, following pointers, which the plan
+// guarantees have already been allocated
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
@@ -0,0 +1,321 @@
+package libconfig_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type plannedConfig struct {
+	VarA   string  `env:"VAR_A"`
+	VarB   int     `env:"VAR_B,optional"`
+	VarC   *uint16 `env:"VAR_C,min=1"`
+	VarD   []int   `env:"VAR_D,json"`
+	Nested *struct {
+		VarE  float64 `env:"VAR_E"`
+		VarF  bool    `env:"VAR_F"`
+		Empty *struct {
+			Untagged string
+		}
+	}
+	Database struct {
+		Host string `env:"HOST"`
+	} `env:"DB_,prefix"`
+}
+
+var plannedEnvs = map[string]string{
+	"VAR_A":   "VAL_A",
+	"VAR_C":   "3",
+	"VAR_D":   "[1,2,3]",
+	"VAR_E":   "1.5",
+	"VAR_F":   "true",
+	"DB_HOST": "localhost",
+}
+
+func TestPlanFor(t *testing.T) {
+	p := mapToParser(nil)
+	plan, err := p.PlanFor(reflect.TypeOf(&plannedConfig{}))
+
+	require := require.New(t)
+	require.NoError(err, "PlanFor should not fail")
+	require.Equal(reflect.TypeOf(plannedConfig{}), plan.Type, "the plan should be for the struct type")
+
+	names := []string{}
+	for _, step := range plan.Steps {
+		if !step.Alloc {
+			names = append(names, step.Name)
+		}
+	}
+	require.Equal([]string{"VAR_A", "VAR_B", "VAR_C", "VAR_D", "VAR_E", "VAR_F", "DB_HOST"}, names, "the plan should contain every tagged field in order")
+	require.Equal([]int{4, 0}, plan.Steps[5].Index, "the index should lead to the nested field")
+	require.Equal("optional", plan.Steps[1].Options, "the options should be available")
+
+	again, err := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	require.NoError(err, "PlanFor should not fail")
+	require.True(plan == again, "the plan should be cached")
+}
+
+func TestPlanForNestedTags(t *testing.T) {
+	type Nested struct {
+		VarC int `json:"varc" env:"VAR_C"`
+	}
+	type Config struct {
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(nil)
+	_, err := p.PlanFor(reflect.TypeOf(Config{}))
+	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+
+	require := require.New(t)
+	require.Equal(expected, err, "PlanFor should fail because the struct is tagged and has tagged members")
+}
+
+func TestPlanForInvalidConfigType(t *testing.T) {
+	p := mapToParser(nil)
+	_, err := p.PlanFor(reflect.TypeOf(1))
+	expected := libconfig.NewErrInvalidConfigType(reflect.TypeOf(1))
+
+	require := require.New(t)
+	require.Equal(expected, err, "PlanFor should fail with ErrInvalidConfigType")
+}
+
+func TestGetWithPlan(t *testing.T) {
+	p := mapToParser(plannedEnvs)
+	plan, err := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	require.NoError(t, err, "PlanFor should not fail")
+
+	naive := plannedConfig{}
+	err = p.Get(&naive)
+	require.NoError(t, err, "Get should not fail")
+
+	planned := plannedConfig{}
+	err = p.GetWithPlan(plan, &planned)
+	require.NoError(t, err, "GetWithPlan should not fail")
+
+	require.Equal(t, naive, planned, "the planned Get should produce identical results")
+	require.NotNil(t, planned.Nested, "nested pointers with fields that are set should be allocated like Get")
+	require.Nil(t, planned.Nested.Empty, "nested pointers with no fields that are set should stay nil like Get")
+}
+
+func TestGetWithPlanError(t *testing.T) {
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+		"VAR_C": "0",
+	})
+	plan, err := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	require.NoError(t, err, "PlanFor should not fail")
+
+	naiveErr := p.Get(&plannedConfig{})
+	plannedErr := p.GetWithPlan(plan, &plannedConfig{})
+
+	require.Error(t, naiveErr, "Get should fail")
+	require.Equal(t, naiveErr, plannedErr, "the planned Get should produce an identical error")
+}
+
+func TestGetWithPlanNestedError(t *testing.T) {
+	env := map[string]string{}
+	for k, v := range plannedEnvs {
+		env[k] = v
+	}
+	delete(env, "VAR_E")
+
+	p := mapToParser(env)
+	plan, err := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	require.NoError(t, err, "PlanFor should not fail")
+
+	naiveErr := p.Get(&plannedConfig{})
+	plannedErr := p.GetWithPlan(plan, &plannedConfig{})
+	expected := libconfig.NewErrField("Nested.VarE", libconfig.NewErrVarNotFound("VAR_E"))
+
+	require.Equal(t, expected, naiveErr, "Get should fail with the path of the field")
+	require.Equal(t, naiveErr, plannedErr, "the planned Get should produce an identical error")
+}
+
+func TestGetWithPlanCollectErrors(t *testing.T) {
+	p := mapToParser(map[string]string{
+		"VAR_C": "0",
+		"VAR_E": "abc",
+	})
+	p.CollectErrors = true
+	plan, err := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	require.NoError(t, err, "PlanFor should not fail")
+
+	naiveErr := p.Get(&plannedConfig{})
+	plannedErr := p.GetWithPlan(plan, &plannedConfig{})
+
+	require.IsType(t, &libconfig.ErrMultiple{}, naiveErr, "Get should collect the errors")
+	require.Len(t, naiveErr.(*libconfig.ErrMultiple).Errors, 6, "every failing field should have an error")
+	require.Equal(t, naiveErr, plannedErr, "the planned Get should collect identical errors in the same order")
+}
+
+func TestGetWithPlanWrongType(t *testing.T) {
+	type Other struct{}
+
+	p := mapToParser(nil)
+	plan, err := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	require.NoError(t, err, "PlanFor should not fail")
+
+	err = p.GetWithPlan(plan, &Other{})
+	expected := libconfig.NewErrInvalidConfigType(reflect.TypeOf(&Other{}))
+	require.Equal(t, expected, err, "GetWithPlan should fail for a different type")
+}
+
+func TestPrepare(t *testing.T) {
+	p := mapToParser(plannedEnvs)
+	naive := plannedConfig{}
+	err := p.Get(&naive)
+	require.NoError(t, err, "Get should not fail")
+
+	err = p.Prepare(&plannedConfig{})
+	require.NoError(t, err, "Prepare should not fail")
+
+	prepared := plannedConfig{}
+	err = p.Get(&prepared)
+	require.NoError(t, err, "Get should not fail")
+	require.Equal(t, naive, prepared, "the prepared Get should produce identical results")
+}
+
+func TestPrepareError(t *testing.T) {
+	env := map[string]string{}
+	for k, v := range plannedEnvs {
+		env[k] = v
+	}
+	env["VAR_C"] = "0"
+	delete(env, "VAR_E")
+
+	p := mapToParser(env)
+	naiveErr := p.Get(&plannedConfig{})
+
+	err := p.RegisterStruct(reflect.TypeOf(plannedConfig{}))
+	require.NoError(t, err, "RegisterStruct should not fail")
+
+	preparedErr := p.Get(&plannedConfig{})
+
+	require.Error(t, naiveErr, "Get should fail")
+	require.Equal(t, naiveErr, preparedErr, "the prepared Get should produce an identical error")
+}
+
+func TestPrepareNestedTags(t *testing.T) {
+	type Nested struct {
+		VarC int `json:"varc" env:"VAR_C"`
+	}
+	type Config struct {
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": `{"varc":1}`,
+	})
+	err := p.Prepare(&Config{})
+	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Prepare should fail because the struct is tagged and has tagged members")
+	require.Equal(expected, p.Get(&Config{}), "Get should still fail the same way without a plan")
+}
+
+func TestPrepareChangedPrefix(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A":     "VAL_A",
+		"APP_VAR_A": "APP_VAL_A",
+	})
+	err := p.Prepare(&Config{})
+	require.NoError(t, err, "Prepare should not fail")
+
+	p.Prefix = "APP_"
+	config := Config{}
+	err = p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("APP_VAL_A", config.VarA, "the plan should not apply to a different prefix")
+}
+
+func TestPrepareChangedAllowNestedTags(t *testing.T) {
+	type Nested struct {
+		VarC int `json:"varc" env:"VAR_C"`
+	}
+	type Config struct {
+		Nested `env:"NESTED,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": `{"varc":1}`,
+	})
+	p.AllowNestedTags = true
+
+	require := require.New(t)
+	require.NoError(p.Prepare(&Config{}), "Prepare should not fail because nested tags are allowed")
+
+	p.AllowNestedTags = false
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrNestedTags("Nested", "NESTED")
+	require.Equal(expected, err, "the prepared plan should not apply once nested tags are not allowed")
+
+	_, err = p.PlanFor(reflect.TypeOf(Config{}))
+	require.Equal(expected, err, "the cached plan should not apply once nested tags are not allowed")
+}
+
+func BenchmarkGetNaive(b *testing.B) {
+	p := mapToParser(plannedEnvs)
+	for i := 0; i < b.N; i++ {
+		config := plannedConfig{}
+		_ = p.Get(&config)
+	}
+}
+
+func BenchmarkGetWithPlan(b *testing.B) {
+	p := mapToParser(plannedEnvs)
+	plan, _ := p.PlanFor(reflect.TypeOf(plannedConfig{}))
+	for i := 0; i < b.N; i++ {
+		config := plannedConfig{}
+		_ = p.GetWithPlan(plan, &config)
+	}
+}
+
+func BenchmarkGetCold(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p := mapToParser(plannedEnvs)
+		config := plannedConfig{}
+		_ = p.Get(&config)
+	}
+}
+
+func BenchmarkGetPrepared(b *testing.B) {
+	p := mapToParser(plannedEnvs)
+	_ = p.Prepare(&plannedConfig{})
+	for i := 0; i < b.N; i++ {
+		config := plannedConfig{}
+		_ = p.Get(&config)
+	}
+}
+
+func TestPrepareChangedNameFunc(t *testing.T) {
+	type Config struct {
+		MaxConns int `env:""`
+	}
+
+	p := mapToParser(map[string]string{
+		"MAX_CONNS": "10",
+		"maxconns":  "20",
+	})
+	p.AutoName = true
+
+	require := require.New(t)
+	require.NoError(p.Prepare(&Config{}), "Prepare should not fail")
+
+	p.NameFunc = strings.ToLower
+	config := Config{}
+	err := p.Get(&config)
+	require.NoError(err, "Get should not fail")
+	require.Equal(20, config.MaxConns, "the prepared plan should not apply with a different NameFunc")
+}
@@ -0,0 +1,107 @@
+package libconfig
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parsePosCSV parses the value as a single line of CSV and sets the exported fields of
+// the struct v, in declaration order, from the columns. More columns than fields is an
+// error. Fewer columns than fields is an error unless the tag has fillmissing, in which
+// case the trailing fields keep their current (default) values.
+func parsePosCSV(v reflect.Value, tag tagData, value []byte) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	r := csv.NewReader(strings.NewReader(string(value)))
+	r.FieldsPerRecord = -1
+	columns, err := r.Read()
+	if err != nil {
+		return NewErrDecodeFailure(err, tag.Name, string(value), "poscsv")
+	}
+
+	fields := []reflect.Value{}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).PkgPath == "" {
+			fields = append(fields, v.Field(i))
+		}
+	}
+
+	if len(columns) > len(fields) || len(columns) < len(fields) && !tag.FillMissing {
+		err = fmt.Errorf("expected %d columns but got %d", len(fields), len(columns))
+		return NewErrDecodeFailure(err, tag.Name, string(value), "poscsv")
+	}
+
+	for i, column := range columns {
+		field := fields[i]
+		if field.Kind() == reflect.Ptr && field.Type() != regexpType {
+			field.Set(reflect.New(field.Type().Elem()))
+			field = field.Elem()
+		}
+
+		err = setValue(field, tag.elem(tag.Name), []byte(column))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseCSVList parses the value as a single line of CSV and sets the slice v to the
+// columns, each parsed as the element type. Columns follow the usual CSV quoting
+// rules: a column containing a comma or a double quote must be enclosed in double
+// quotes, and a double quote within it is written twice, e.g. a,"b,c","say ""hi""".
+// Elements that are a time.Duration are always parsed as such. Errors identify the
+// index of the failing element, e.g. TAGS[1].
+func parseCSVList(v reflect.Value, tag tagData, value []byte) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	r := csv.NewReader(strings.NewReader(string(value)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	columns, err := r.Read()
+	if err == io.EOF {
+		columns, err = nil, nil
+	}
+	if err != nil {
+		return NewErrDecodeFailure(err, tag.Name, string(value), "csv")
+	}
+
+	list := reflect.MakeSlice(v.Type(), len(columns), len(columns))
+	for i, column := range columns {
+		elemTag := tag
+		elemTag.Name = tag.Name + "[" + strconv.Itoa(i) + "]"
+
+		elem := list.Index(i)
+		if elem.Kind() == reflect.Ptr && elem.Type() != regexpType {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			elem = elem.Elem()
+		}
+
+		if elem.Type() == durationType {
+			err = setValueWithUnits(elem, elemTag.Name, column, 0)
+		} else {
+			err = setValue(elem, elemTag, []byte(column))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	v.Set(list)
+	return nil
+}
@@ -0,0 +1,294 @@
+package libconfig_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type address struct {
+	Host  string
+	Port  int
+	Proto string
+}
+
+func TestPosCSV(t *testing.T) {
+	type Config struct {
+		Addr  address  `env:"ADDR,poscsv"`
+		Other *address `env:"OTHER,poscsv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADDR":  "localhost,8080,tcp",
+		"OTHER": `"quoted,host",53,udp`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(address{"localhost", 8080, "tcp"}, config.Addr, "Addr should parse correctly")
+	require.Equal(&address{"quoted,host", 53, "udp"}, config.Other, "Other should parse correctly")
+}
+
+func TestPosCSVFillMissing(t *testing.T) {
+	type Config struct {
+		Addr address `env:"ADDR,poscsv,fillmissing"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADDR": "host",
+	})
+
+	config := Config{
+		Addr: address{Port: 80, Proto: "tcp"},
+	}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(address{"host", 80, "tcp"}, config.Addr, "missing columns should keep their defaults")
+}
+
+func TestPosCSVTooFewColumns(t *testing.T) {
+	type Config struct {
+		Addr address `env:"ADDR,poscsv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADDR": "host",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail without fillmissing")
+	_, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+}
+
+func TestPosCSVTooManyColumns(t *testing.T) {
+	type Config struct {
+		Addr address `env:"ADDR,poscsv,fillmissing"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADDR": "host,80,tcp,extra",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail even with fillmissing")
+	_, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+}
+
+func TestPosCSVBadColumn(t *testing.T) {
+	type Config struct {
+		Addr address `env:"ADDR,poscsv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"ADDR": "host,not-an-int,tcp",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the port")
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal(reflect.Int, specificErr.Kind, "the error should be for the int field")
+}
+
+func TestPosCSVParserSettings(t *testing.T) {
+	type Listener struct {
+		Port int
+		TLS  bool
+	}
+	type Config struct {
+		Listener Listener `env:"LISTENER,poscsv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LISTENER": "443.0,yes",
+	})
+	p.CoerceFloatToInt = true
+	p.ExtendedBools = true
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(Listener{Port: 443, TLS: true}, config.Listener, "the columns should be decoded with the Parser's settings")
+}
+
+func TestPosCSVNotStruct(t *testing.T) {
+	type Config struct {
+		Addr string `env:"ADDR,poscsv"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("ADDR,poscsv", "poscsv")
+
+	require := require.New(t)
+	require.Equal(expected, err, "poscsv should only apply to structs")
+}
+
+func TestFillMissingWithoutPosCSV(t *testing.T) {
+	type Config struct {
+		Addr address `env:"ADDR,fillmissing,json"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("ADDR,fillmissing,json", "fillmissing")
+
+	require := require.New(t)
+	require.Equal(expected, err, "fillmissing should require poscsv")
+}
+
+func TestCSVList(t *testing.T) {
+	type Config struct {
+		Tags   []string `env:"TAGS,csv"`
+		Quoted []string `env:"QUOTED,csv"`
+		Ports  []int    `env:"PORTS,csv"`
+		Empty  []string `env:"EMPTY,csv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TAGS":   `a,"b,c",d`,
+		"QUOTED": `"say ""hi""", plain`,
+		"PORTS":  "80, 443",
+		"EMPTY":  "",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]string{"a", "b,c", "d"}, config.Tags, "a quoted column may contain commas")
+	require.Equal([]string{`say "hi"`, "plain"}, config.Quoted, "a doubled quote should be unescaped")
+	require.Equal([]int{80, 443}, config.Ports, "the elements should be parsed as the element type")
+	require.Equal([]string{}, config.Empty, "an empty value should be an empty slice")
+}
+
+func TestCSVListBadElement(t *testing.T) {
+	type Config struct {
+		Ports []int `env:"PORTS,csv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PORTS": "80,http",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("PORTS[1]", specificErr.Key, "the error should identify the element")
+}
+
+func TestCSVListBadQuotes(t *testing.T) {
+	type Config struct {
+		Tags []string `env:"TAGS,csv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"TAGS": `a,"b`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("csv", specificErr.Type, "the error should be for csv")
+}
+
+func TestCSVListNotSlice(t *testing.T) {
+	type Config struct {
+		Tags string `env:"TAGS,csv"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("TAGS,csv", "csv")
+
+	require := require.New(t)
+	require.Equal(expected, err, "csv should only apply to slices")
+}
+
+func TestDurationList(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration  `env:"BACKOFFS"`
+		Pointers []*time.Duration `env:"POINTERS,csv"`
+		Empty    []time.Duration  `env:"EMPTY"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BACKOFFS": "1s,2s,4s",
+		"POINTERS": "500ms, 1h30m",
+		"EMPTY":    "",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, config.Backoffs, "Backoffs should have three durations")
+	require.Len(config.Pointers, 2, "Pointers should have two durations")
+	require.Equal(90*time.Minute, *config.Pointers[1], "each element of Pointers should be parsed as a duration")
+	require.Empty(config.Empty, "Empty should have no durations")
+}
+
+func TestDurationListBadElement(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration `env:"BACKOFFS"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BACKOFFS": "1s,2x,4s",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	specificErr, ok := err.(*libconfig.ErrCannotParseEnv)
+	require.True(ok, "the error should be ErrCannotParseEnv")
+	require.Equal("BACKOFFS[1]", specificErr.Key, "the error should identify the element")
+	require.Equal("2x", specificErr.Value, "the error should include the element")
+}
+
+func TestDurationListJSON(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration `env:"BACKOFFS,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"BACKOFFS": "[1000000000]",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal([]time.Duration{time.Second}, config.Backoffs, "json should still decode the list as a whole")
+}
@@ -0,0 +1,109 @@
+package libconfig
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// prefixMapNames returns the sorted names of the variables listed by the EnumFn that
+// begin with the prefix named in the tag of a map tagged with prefixmap, other than
+// the prefix itself. Without an EnumFn, the variables cannot be found, which is an
+// ErrLookupFailed wrapping ErrEnumUnavailable.
+func (p *Parser) prefixMapNames(tag tagData) ([]string, error) {
+	if p.EnumFn == nil {
+		return nil, NewErrLookupFailed(tag.Name, ErrEnumUnavailable)
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range p.EnumFn() {
+		if len(name) > len(tag.Name) && strings.HasPrefix(name, tag.Name) && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// retrievePrefixMap populates a map tagged with prefixmap from every variable whose
+// name begins with the prefix, e.g. FLAG_A and FLAG_B for FLAG_. The rest of each
+// name is parsed as the key, and each value is decoded as an element of the map. If
+// none are found, the field is treated like any other variable that is not found.
+func (p *Parser) retrievePrefixMap(state *getState, v reflect.Value, tag tagData) error {
+	names, err := p.prefixMapNames(tag)
+	if err != nil {
+		return err
+	}
+
+	t := v.Type()
+	m := reflect.MakeMap(t)
+
+	for _, name := range names {
+		value, found, err := p.lookupVar(state.ctx, name)
+		if err != nil {
+			return err
+		}
+		if state.found != nil {
+			state.found[name] = found
+		}
+		if !found {
+			continue
+		}
+
+		elemTag := tag
+		elemTag.Name = name
+
+		// Elements that are durations are always parsed as such
+		if derefType(t.Elem()) == durationType {
+			elemTag.Unit = true
+		}
+
+		if tag.Expand {
+			value, err = p.expand(state.ctx, elemTag, value)
+			if err != nil {
+				return err
+			}
+		}
+
+		key := reflect.New(t.Key()).Elem()
+		err = setValue(key, tagData{Name: name}, []byte(strings.TrimPrefix(name, tag.Name)))
+		if err != nil {
+			return err
+		}
+
+		elem := reflect.New(t.Elem()).Elem()
+		err = p.assign(state, elem, elemTag, value)
+		if err != nil {
+			return err
+		}
+
+		m.SetMapIndex(key, elem)
+	}
+
+	if m.Len() == 0 {
+		if !tag.Optional {
+			return NewErrVarNotFound(tag.Name)
+		}
+
+		if v.IsZero() {
+			return nil
+		}
+
+		return validate(v, tag)
+	}
+
+	v.Set(m)
+	state.resolved++
+
+	err = validate(v, tag)
+	if err != nil {
+		return err
+	}
+
+	p.report(state, tag.Name, tag.Name, SourceLookup)
+
+	return nil
+}
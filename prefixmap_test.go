@@ -0,0 +1,120 @@
+package libconfig_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestPrefixMap(t *testing.T) {
+	type Config struct {
+		Flags map[string]bool `env:"FLAG_,prefixmap"`
+	}
+
+	p := mapToParser(map[string]string{
+		"FLAG_A":    "1",
+		"FLAG_B":    "0",
+		"FLAG_BETA": "true",
+		"OTHER":     "true",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]bool{"A": true, "B": false, "BETA": true}, config.Flags, "Flags should be keyed by the rest of each name")
+}
+
+func TestPrefixMapNoneFound(t *testing.T) {
+	type Config struct {
+		Flags map[string]bool `env:"FLAG_,prefixmap"`
+	}
+
+	p := mapToParser(map[string]string{
+		"FLAG_": "1",
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrVarNotFound("FLAG_")
+
+	require.Equal(t, expected, err, "Get should fail because no variables have the prefix")
+}
+
+func TestPrefixMapOptional(t *testing.T) {
+	type Config struct {
+		Flags map[string]bool `env:"FLAG_,prefixmap,optional"`
+	}
+
+	p := mapToParser(nil)
+
+	config := Config{Flags: map[string]bool{"A": true}}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(map[string]bool{"A": true}, config.Flags, "Flags should keep its default")
+}
+
+func TestPrefixMapBadValue(t *testing.T) {
+	type Config struct {
+		Limits map[string]int `env:"LIMIT_,prefixmap"`
+	}
+
+	p := mapToParser(map[string]string{
+		"LIMIT_A": "1",
+		"LIMIT_B": "lots",
+	})
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	var specificErr *libconfig.ErrCannotParseEnv
+	require.ErrorAs(err, &specificErr, "the error should be ErrCannotParseEnv")
+	require.Equal("LIMIT_B", specificErr.Key, "the error should be for the variable")
+}
+
+func TestPrefixMapWithoutEnumFn(t *testing.T) {
+	type Config struct {
+		Flags map[string]bool `env:"FLAG_,prefixmap"`
+	}
+
+	p := mapToParser(map[string]string{
+		"FLAG_A": "1",
+	})
+	p.EnumFn = nil
+
+	err := p.Get(&Config{})
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrLookupFailed{}, err, "the error should be ErrLookupFailed")
+	require.True(errors.Is(err, libconfig.ErrEnumUnavailable), "the error should wrap ErrEnumUnavailable")
+}
+
+func TestPrefixMapInvalidType(t *testing.T) {
+	type Config struct {
+		Flags []bool `env:"FLAG_,prefixmap"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+
+	expected := libconfig.NewErrInvalidTagOption("FLAG_,prefixmap", "prefixmap")
+	require.Equal(t, expected, err, "prefixmap requires a map")
+}
+
+func TestDumpPrefixMap(t *testing.T) {
+	type Config struct {
+		Flags map[string]bool `env:"FLAG_,prefixmap"`
+	}
+
+	p := mapToParser(nil)
+	values, err := p.Dump(&Config{Flags: map[string]bool{"A": true, "B": false}})
+
+	require := require.New(t)
+	require.NoError(err, "Dump should not fail")
+	require.Equal(map[string]string{"FLAG_A": "true", "FLAG_B": "false"}, values, "each entry should be a variable")
+}
@@ -1,15 +1,126 @@
 package libconfig
 
 import (
+	"database/sql"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
-// setValue parses the bytes into a reflect.Value
-func setValue(v reflect.Value, key string, value []byte) error {
+// ErrNegativeUnsigned is the cause of the ErrCannotParseEnv returned if the value for
+// an unsigned integer is negative
+var ErrNegativeUnsigned = errors.New("negative values are not allowed for unsigned types")
+
+// atomicTypes maps the sync/atomic wrapper types to the types of the values they hold
+var atomicTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(atomic.Int32{}):  reflect.TypeOf(int32(0)),
+	reflect.TypeOf(atomic.Int64{}):  reflect.TypeOf(int64(0)),
+	reflect.TypeOf(atomic.Uint32{}): reflect.TypeOf(uint32(0)),
+	reflect.TypeOf(atomic.Uint64{}): reflect.TypeOf(uint64(0)),
+	reflect.TypeOf(atomic.Bool{}):   reflect.TypeOf(false),
+}
+
+// regexpType is the type of a compiled regular expression, which is set by compiling
+// the value
+var regexpType = reflect.TypeOf((*regexp.Regexp)(nil))
+
+// hardwareAddrType is the type of a MAC address, which is parsed from its text form
+// rather than set as raw bytes
+var hardwareAddrType = reflect.TypeOf(net.HardwareAddr{})
+
+// isHardwareAddr returns true if the value for the type is a MAC address in its text
+// form, i.e. it is a net.HardwareAddr that is not encoded as base64, hex, or JSON
+func isHardwareAddr(t reflect.Type, tag tagData) bool {
+	return t == hardwareAddrType && !tag.Base64 && !tag.Hex && !tag.JSON
+}
+
+// scannerType is the interface implemented by the nullable types of database/sql
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// isScanner returns true if a pointer to the type implements sql.Scanner
+func isScanner(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(scannerType)
+}
+
+// ErrInvalidCalendarValue is the cause of the ErrCannotParseEnv returned if the value
+// for a time.Weekday or time.Month is neither its English name nor its number
+var ErrInvalidCalendarValue = errors.New("value must be the English name or number of a weekday or month")
+
+// weekdayType and monthType are named integers that are parsed by name or number
+var (
+	weekdayType = reflect.TypeOf(time.Sunday)
+	monthType   = reflect.TypeOf(time.January)
+)
+
+// ErrFractional is the cause of the ErrCannotParseEnv returned if a value written as a
+// float has a fractional part but the field is an integer
+var ErrFractional = errors.New("integer value cannot have a fractional part")
+
+// setValue parses the bytes into a reflect.Value. The tag determines how the bytes
+// are interpreted, e.g. as a number with units. Values tagged as json never get here,
+// so a []byte is always set to the bytes themselves.
+func setValue(v reflect.Value, tag tagData, value []byte) error {
 	var f func(reflect.Value, reflect.Kind, string, string) error
 	k := v.Kind()
 
+	// Atomic wrappers, e.g. atomic.Int64, are set by storing the parsed value
+	if t, ok := atomicTypes[v.Type()]; ok {
+		return setValueToAtomic(v, t, tag, value)
+	}
+
+	// Regular expressions are compiled
+	if v.Type() == regexpType {
+		return setValueToRegexp(v, tag.Name, string(value))
+	}
+
+	// MAC addresses are parsed, e.g. 01:23:45:67:89:ab
+	if isHardwareAddr(v.Type(), tag) {
+		return setValueToHardwareAddr(v, tag.Name, string(value))
+	}
+
+	// Days of the week and months, e.g. Monday or 1
+	if t := v.Type(); t == weekdayType || t == monthType {
+		return setValueToCalendar(v, tag.Name, string(value))
+	}
+
+	// Numbers written with a thousands separator, e.g. 1,000,000
+	if tag.Thousands != "" && (isInt(k) || isUint(k) || isFloat(k)) {
+		value = []byte(strings.ReplaceAll(string(value), tag.Thousands, ""))
+	}
+
+	// Human-readable values with units, e.g. durations and byte sizes
+	if tag.Unit {
+		return setValueWithUnits(v, tag.Name, string(value), durationUnits[tag.DurationUnit])
+	}
+
+	// Durations converted to whole minutes, e.g. 1h30m is 90
+	if tag.Minutes {
+		return setValueToMinutes(v, tag.Name, string(value), tag.MinutesRound)
+	}
+
+	// A single character as its code point, e.g. a rune
+	if tag.Char {
+		return setValueToChar(v, k, tag.Name, string(value))
+	}
+
+	// Integers written as floats, e.g. "10.0", if the Parser allows it
+	if tag.CoerceFloat && tag.base() == 10 && (isInt(k) || isUint(k)) {
+		coerced, err := coerceFloatToInt(k, tag.Name, string(value))
+		if err != nil {
+			return err
+		}
+		value = []byte(coerced)
+	}
+
 	switch k {
 
 	// []byte
@@ -19,6 +130,12 @@ func setValue(v reflect.Value, key string, value []byte) error {
 			return nil
 		}
 
+	// [N]byte, which must be exactly N bytes
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return setValueToByteArray(v, tag.Name, value)
+		}
+
 	// string
 	case reflect.String:
 		v.SetString(string(value))
@@ -26,11 +143,11 @@ func setValue(v reflect.Value, key string, value []byte) error {
 
 	// int
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		f = setValueToInt
+		return setValueToInt(v, k, tag.Name, string(value), tag.base(), tag.Wrap)
 
 	// uint
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		f = setValueToUint
+		return setValueToUint(v, k, tag.Name, string(value), tag.base(), tag.Wrap)
 
 	// float
 	case reflect.Float32, reflect.Float64:
@@ -39,22 +156,187 @@ func setValue(v reflect.Value, key string, value []byte) error {
 	// bool
 	case reflect.Bool:
 		f = setValueToBool
+		if tag.Truthy {
+			f = setValueToExtendedBool
+		}
+		if tag.IntBool {
+			f = setValueToIntBool
+		}
 	}
 
 	if f == nil {
+		// A non-nil interface is filled by the value it holds, e.g. one preset by the
+		// caller, if that implements encoding.TextUnmarshaler
+		if k == reflect.Interface && !v.IsNil() {
+			if ok, err := setValueToInterface(v, tag, value); ok {
+				return err
+			}
+		}
+
+		// Types from database/sql, e.g. sql.NullString, scan the value as a string and
+		// are valid only if it is found
+		if v.CanAddr() && isScanner(v.Type()) {
+			err := v.Addr().Interface().(sql.Scanner).Scan(string(value))
+			if err != nil {
+				return NewErrDecodeFailure(err, tag.Name, string(value), "scan")
+			}
+			return nil
+		}
+
+		// As a last resort, use UnmarshalJSON if the type implements json.Unmarshaler.
+		// Tagging the field with json always forces JSON decoding, so this only matters
+		// for types that libconfig cannot otherwise set.
+		if v.CanAddr() {
+			if u, ok := v.Addr().Interface().(json.Unmarshaler); ok {
+				err := u.UnmarshalJSON(value)
+				if err != nil {
+					return NewErrDecodeFailure(err, tag.Name, string(value), "json")
+				}
+				return nil
+			}
+		}
+
 		return NewErrCannotSetKind(k)
 	}
 
-	return f(v, k, key, string(value))
+	return f(v, k, tag.Name, string(value))
+}
+
+// setValueToAtomic parses the value as the type held by the atomic wrapper v and
+// stores it
+func setValueToAtomic(v reflect.Value, t reflect.Type, tag tagData, value []byte) error {
+	parsed := reflect.New(t).Elem()
+	err := setValue(parsed, tag, value)
+	if err != nil {
+		return err
+	}
+
+	v.Addr().MethodByName("Store").Call([]reflect.Value{parsed})
+	return nil
+}
+
+// setValueToInterface unmarshals the value into the concrete value held by the
+// interface v using UnmarshalText. A pointer is filled in place, while any other value
+// is copied and then replaced. It returns false if the concrete type does not
+// implement encoding.TextUnmarshaler.
+func setValueToInterface(v reflect.Value, tag tagData, value []byte) (bool, error) {
+	elem := v.Elem()
+
+	target := elem
+	if elem.Kind() != reflect.Ptr {
+		target = reflect.New(elem.Type())
+		target.Elem().Set(elem)
+	} else if elem.IsNil() {
+		return false, nil
+	}
+
+	u, ok := target.Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return false, nil
+	}
+
+	err := u.UnmarshalText(value)
+	if err != nil {
+		return true, NewErrDecodeFailure(err, tag.Name, string(value), "text")
+	}
+
+	if elem.Kind() != reflect.Ptr {
+		v.Set(target.Elem())
+	}
+
+	return true, nil
+}
+
+// setValueToByteArray copies the bytes into v, which is a [N]byte, returning an
+// ErrWrongLength unless there are exactly N of them
+func setValueToByteArray(v reflect.Value, key string, value []byte) error {
+	if len(value) != v.Len() {
+		return NewErrWrongLength(key, v.Len(), len(value))
+	}
+
+	reflect.Copy(v, reflect.ValueOf(value))
+	return nil
+}
+
+// setValueToRegexp compiles the value and sets v, which is a *regexp.Regexp, to the
+// result
+func setValueToRegexp(v reflect.Value, key, value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return NewErrCannotParseEnv(err, v.Kind(), key, value)
+	}
+
+	v.Set(reflect.ValueOf(re))
+	return nil
+}
+
+// setValueToCalendar parses the value as the English name of a time.Weekday or
+// time.Month, in any case, or as its number, i.e. 0 to 6 from Sunday or 1 to 12
+func setValueToCalendar(v reflect.Value, key, value string) error {
+	first, last := int(time.Sunday), int(time.Saturday)
+	name := func(i int) string { return time.Weekday(i).String() }
+	if v.Type() == monthType {
+		first, last = int(time.January), int(time.December)
+		name = func(i int) string { return time.Month(i).String() }
+	}
+
+	for i := first; i <= last; i++ {
+		if strings.EqualFold(value, name(i)) {
+			v.SetInt(int64(i))
+			return nil
+		}
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < first || i > last {
+		return NewErrCannotParseEnv(ErrInvalidCalendarValue, v.Kind(), key, value)
+	}
+
+	v.SetInt(int64(i))
+	return nil
+}
+
+// setValueToHardwareAddr parses the value as a MAC address
+func setValueToHardwareAddr(v reflect.Value, key, value string) error {
+	addr, err := net.ParseMAC(value)
+	if err != nil {
+		return NewErrCannotParseEnv(err, v.Kind(), key, value)
+	}
+
+	v.Set(reflect.ValueOf(addr))
+	return nil
+}
+
+// coerceFloatToInt rewrites a value written as a float, e.g. "10.0" or "1e3", as an
+// integer, returning an error if it has a fractional part. Other values, including
+// integers, are returned unchanged so that they are parsed without losing precision.
+func coerceFloatToInt(k reflect.Kind, key, value string) (string, error) {
+	if !strings.ContainsAny(value, ".eE") {
+		return value, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value, nil
+	}
+
+	if f != math.Trunc(f) {
+		return "", NewErrCannotParseEnv(ErrFractional, k, key, value)
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
 }
 
-func setValueToInt(v reflect.Value, k reflect.Kind, key, value string) error {
-	intVal, err := strconv.ParseInt(value, 10, 64)
+// setValueToInt parses the value as a 64-bit integer and then checks that it fits in
+// v, whose width, for int, depends on the platform, unless it should wrap around
+func setValueToInt(v reflect.Value, k reflect.Kind, key, value string, base int, wrap bool) error {
+	intVal, err := strconv.ParseInt(value, base, 64)
 	if err != nil {
 		return NewErrCannotParseEnv(err, k, key, value)
 	}
 
-	if v.OverflowInt(intVal) {
+	// SetInt truncates to the width of the field, like a C cast
+	if !wrap && v.OverflowInt(intVal) {
 		return NewErrOverflow(k, key, value)
 	}
 
@@ -62,13 +344,19 @@ func setValueToInt(v reflect.Value, k reflect.Kind, key, value string) error {
 	return nil
 }
 
-func setValueToUint(v reflect.Value, k reflect.Kind, key, value string) error {
-	uintVal, err := strconv.ParseUint(value, 10, 64)
+// setValueToUint is like setValueToInt, but for unsigned integers, rejecting negative
+// values rather than letting them wrap around
+func setValueToUint(v reflect.Value, k reflect.Kind, key, value string, base int, wrap bool) error {
+	if strings.HasPrefix(value, "-") {
+		return NewErrCannotParseEnv(ErrNegativeUnsigned, k, key, value)
+	}
+
+	uintVal, err := strconv.ParseUint(value, base, 64)
 	if err != nil {
 		return NewErrCannotParseEnv(err, k, key, value)
 	}
 
-	if v.OverflowUint(uintVal) {
+	if !wrap && v.OverflowUint(uintVal) {
 		return NewErrOverflow(k, key, value)
 	}
 
@@ -99,3 +387,58 @@ func setValueToBool(v reflect.Value, k reflect.Kind, key, value string) error {
 	v.SetBool(boolVal)
 	return nil
 }
+
+// extendedBools holds the words accepted by setValueToExtendedBool, in lower case
+var extendedBools = map[string]bool{
+	"yes":      true,
+	"no":       false,
+	"on":       true,
+	"off":      false,
+	"enabled":  true,
+	"disabled": false,
+}
+
+// setValueToExtendedBool is like setValueToBool, but also accepts the words in
+// extendedBools, in any case
+func setValueToExtendedBool(v reflect.Value, k reflect.Kind, key, value string) error {
+	if boolVal, ok := extendedBools[strings.ToLower(value)]; ok {
+		v.SetBool(boolVal)
+		return nil
+	}
+
+	return setValueToBool(v, k, key, value)
+}
+
+// setValueToIntBool parses the value as an integer, which is true unless it is zero,
+// e.g. 2 is true, unlike with setValueToBool
+func setValueToIntBool(v reflect.Value, k reflect.Kind, key, value string) error {
+	intVal, err := strconv.Atoi(value)
+	if err != nil {
+		return NewErrCannotParseEnv(err, k, key, value)
+	}
+
+	v.SetBool(intVal != 0)
+	return nil
+}
+
+func setValueToChar(v reflect.Value, k reflect.Kind, key, value string) error {
+	r, size := utf8.DecodeRuneInString(value)
+	if r == utf8.RuneError && size <= 1 || size != len(value) {
+		err := errors.New("expected a single UTF-8 character")
+		return NewErrCannotParseEnv(err, k, key, value)
+	}
+
+	if isInt(k) {
+		if v.OverflowInt(int64(r)) {
+			return NewErrOverflow(k, key, value)
+		}
+		v.SetInt(int64(r))
+		return nil
+	}
+
+	if v.OverflowUint(uint64(r)) {
+		return NewErrOverflow(k, key, value)
+	}
+	v.SetUint(uint64(r))
+	return nil
+}
@@ -0,0 +1,120 @@
+package libconfig
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GetSources returns a map from the path of each field of the config, e.g.
+// "Database.Host" or "Servers[0].Port", to the name of the variable that would populate it, accounting for
+// prefixes and alternate names. Fields whose variables are not found are omitted.
+// The values are not parsed, so GetSources succeeds even if Get would fail to parse
+// them. The config may be a struct or a pointer to a struct and is not modified.
+func (p *Parser) GetSources(config interface{}) (map[string]string, error) {
+	t, err := configType(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := map[string]string{}
+	err = p.sources(t, p.Prefix, "", sources)
+	if err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// sources adds the path and resolved variable name of each field of the struct type
+// to the map, following the same rules for nested structs as parse
+func (p *Parser) sources(t reflect.Type, prefix, path string, sources map[string]string) error {
+	fields, err := p.fields(t, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		fieldPath := path + f.Field.Name
+
+		tag := f.Tag
+		if tag.Indexed {
+			for i := 0; ; i++ {
+				prefix := indexedPrefix(tag.Name, i)
+				_, found, err := p.probeIndex(context.Background(), f.Field.Type.Elem(), prefix)
+				if err != nil {
+					return err
+				}
+				if !found {
+					break
+				}
+
+				err = p.sources(f.Field.Type.Elem(), prefix, fieldPath+"["+strconv.Itoa(i)+"].", sources)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if tag.Numbered {
+			names := map[string]bool{}
+			err = p.numberedNames(tag, names)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < len(names); i++ {
+				sources[fieldPath+"["+strconv.Itoa(i)+"]"] = numberedName(tag.Name, tag.NumberFrom+i)
+			}
+			continue
+		}
+
+		if tag.PrefixMap {
+			names, err := p.prefixMapNames(tag)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				_, found, err := p.lookupVar(context.Background(), name)
+				if err != nil {
+					return err
+				}
+				if found {
+					sources[fieldPath+"["+strings.TrimPrefix(name, tag.Name)+"]"] = name
+				}
+			}
+			continue
+		}
+
+		if tag.Tagged && !tag.Prefix {
+			name, _, found, err := p.lookup(context.Background(), tag)
+			if err != nil {
+				return err
+			}
+			if found {
+				sources[fieldPath] = name
+			}
+			continue
+		}
+
+		nestedPrefix := prefix
+		if tag.Prefix {
+			nestedPrefix = tag.Name
+		}
+
+		if f.IsStruct {
+			ft := f.Field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			err = p.sources(ft, nestedPrefix, fieldPath+".", sources)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package libconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestGetSources(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,alt=OLD_PORT"`
+		User string `env:"USER,optional"`
+	}
+	type Config struct {
+		VarA     string    `env:"VAR_A"`
+		Database *Database `env:"DB_,prefix"`
+	}
+
+	p := mapToParser(map[string]string{
+		"APP_VAR_A":       "VAL_A",
+		"APP_DB_HOST":     "localhost",
+		"APP_DB_OLD_PORT": "not-a-port",
+	})
+	p.Prefix = "APP_"
+
+	config := Config{}
+	sources, err := p.GetSources(&config)
+	expected := map[string]string{
+		"VarA":          "APP_VAR_A",
+		"Database.Host": "APP_DB_HOST",
+		"Database.Port": "APP_DB_OLD_PORT",
+	}
+
+	require := require.New(t)
+	require.NoError(err, "GetSources should not fail even though the port cannot be parsed")
+	require.Equal(expected, sources, "sources should contain the names that were found")
+	require.Nil(config.Database, "GetSources should not modify the config")
+}
+
+func TestGetSourcesEmbedded(t *testing.T) {
+	type Embedded struct {
+		VarB string `env:"VAR_B"`
+	}
+	type Config struct {
+		Embedded
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_B": "VAL_B",
+	})
+
+	sources, err := p.GetSources(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "GetSources should not fail")
+	require.Equal(map[string]string{"Embedded.VarB": "VAR_B"}, sources, "the path should include the embedded struct")
+}
+
+func TestGetSourcesBadTag(t *testing.T) {
+	type Config struct {
+		VarA string `env:""`
+	}
+
+	p := mapToParser(nil)
+	_, err := p.GetSources(&Config{})
+	expected := libconfig.NewErrMissingNameTag("")
+
+	require := require.New(t)
+	require.Equal(expected, err, "GetSources should fail because of the tag")
+}
+
+func TestGetSourcesStructValue(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(map[string]string{
+		"VAR_A": "VAL_A",
+	})
+	sources, err := p.GetSources(Config{})
+
+	require := require.New(t)
+	require.NoError(err, "GetSources should accept a struct value")
+	require.Equal(map[string]string{"VarA": "VAR_A"}, sources, "sources should be the same as for a pointer")
+}
+
+func TestGetSourcesInvalidConfigType(t *testing.T) {
+	p := mapToParser(nil)
+
+	var config int
+	_, err := p.GetSources(&config)
+
+	require := require.New(t)
+	require.IsType(&libconfig.ErrInvalidConfigType{}, err, "GetSources should require a struct")
+}
@@ -0,0 +1,44 @@
+package libconfig
+
+import "context"
+
+// getState holds the state of a single call to Get (or one of its variants) as it
+// is threaded through parse and retrieve
+type getState struct {
+	ctx context.Context
+
+	// found, if not nil, records whether each variable was found
+	found map[string]bool
+
+	// resolutions, if not nil, records where the value of each variable came from
+	resolutions map[string]Resolution
+
+	// resolved counts the fields that have been set, so that a pointer allocated for a
+	// nested struct can be reset to nil if none of its fields are
+	resolved int
+
+	// errs holds the errors for the fields that failed if the Parser collects errors
+	errs []error
+}
+
+// collected returns the error that stopped parsing, if any, or, if errors were
+// collected, an ErrMultiple holding them followed by that error
+func (s *getState) collected(err error) error {
+	if len(s.errs) == 0 {
+		return err
+	}
+
+	errs := s.errs
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return NewErrMultiple(errs)
+}
+
+// newGetState creates the state for a call to Get with the given context
+func newGetState(ctx context.Context) *getState {
+	return &getState{
+		ctx: ctx,
+	}
+}
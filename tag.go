@@ -2,23 +2,347 @@ package libconfig
 
 import (
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type tagData struct {
-	Tagged   bool
-	Name     string
-	Optional bool
-	Base64   bool
-	JSON     bool
+	Tagged       bool
+	Name         string
+	Optional     bool
+	Base64       bool
+	JSON         bool
+	PosCSV       bool
+	FillMissing  bool
+	Prefix       bool
+	OneOf        []string
+	Fuzzy        bool
+	Stdin        bool
+	Min          string
+	Max          string
+	MinLen       string
+	MaxLen       string
+	Pattern      string
+	Regexp       *regexp.Regexp
+	KDF          string
+	Salt         string
+	Unit         bool
+	Unmarshaler  string
+	Gzip         bool
+	EmptyUnset   bool
+	Expand       bool
+	ExpandStrict bool
+	Alt          []string
+	Char         bool
+	Base         string
+	Indexed      bool
+	DurationUnit string
+	HasDefault   bool
+	Default      string
+	CoerceFloat  bool
+	Hex          bool
+	Truthy       bool
+	FromFile     bool
+	CSV          bool
+	Lower        bool
+	Upper        bool
+	Numbered     bool
+	NumberFrom   int
+	Secret       bool
+	Thousands    string
+	Wrap         bool
+	Message      string
+	Presence     bool
+	KV           bool
+	Deprecated   bool
+	PrefixMap    bool
+	Minutes      bool
+	MinutesRound bool
+	IntBool      bool
+	AutoNamed    bool
 }
 
-func parseTag(f reflect.StructField, tag string) (tagData, error) {
+// tagRules holds the settings of the Parser that affect how tags are parsed
+type tagRules struct {
+	// AutoName allows the name to be omitted, e.g. `env:",optional"`
+	AutoName bool
+
+	// IgnoreUnknownOptions skips unknown options rather than returning an error
+	IgnoreUnknownOptions bool
+}
+
+// tagOption describes how a tag option is parsed
+type tagOption struct {
+	// parse sets the option in the tag data, given the type of the field and the
+	// argument of an option that takes one, and returns false if the option does not
+	// apply to the type or the argument is invalid
+	parse func(result *tagData, t reflect.Type, arg string) bool
+
+	// rest is set if the argument is the rest of the tag, since it may contain commas
+	rest bool
+}
+
+// isIntOrUint returns true if the kind of the type, dereferencing any pointers, is an
+// integer
+func isIntOrUint(t reflect.Type) bool {
+	k := elemKind(t)
+	return isInt(k) || isUint(k)
+}
+
+// tagOptions holds the options recognized by parseTag by name, with a trailing "=" for
+// those that take an argument. parseTag treats any other option as unknown unless it is
+// registered with RegisterUnmarshaler.
+var tagOptions = map[string]tagOption{
+	"alt=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		for _, alt := range strings.Split(arg, "|") {
+			if alt == "" {
+				return false
+			}
+			result.Alt = append(result.Alt, alt)
+		}
+		return true
+	}},
+	"base=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n == 1 || n < 0 || n > 36 || !isIntOrUint(t) {
+			return false
+		}
+		result.Base = arg
+		return true
+	}},
+	"base64": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Base64 = true
+		return true
+	}},
+	"char": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Char = true
+		return isIntOrUint(t)
+	}},
+	"csv": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.CSV = true
+		t = derefType(t)
+		return t.Kind() == reflect.Slice && !isBytes(t)
+	}},
+	"default=": {rest: true, parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Default = arg
+		result.HasDefault = true
+		return true
+	}},
+	"deprecated": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Deprecated = true
+		return true
+	}},
+	"emptyasunset": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.EmptyUnset = true
+		return true
+	}},
+	"expand": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Expand = true
+		return true
+	}},
+	"expand=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Expand = true
+		result.ExpandStrict = true
+		return arg == "strict"
+	}},
+	"fillmissing": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.FillMissing = true
+		return true
+	}},
+	"fromfile": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.FromFile = true
+		return true
+	}},
+	"fuzzy": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Fuzzy = true
+		return true
+	}},
+	"gzip": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Gzip = true
+		return true
+	}},
+	"hex": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Hex = true
+		return isByteArray(t)
+	}},
+	"indexed": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Indexed = true
+		return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct
+	}},
+	"intbool": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.IntBool = true
+		return elemKind(t) == reflect.Bool
+	}},
+	"json": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.JSON = true
+		return true
+	}},
+	"kdf=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.KDF = arg
+		return arg != "" && isBytes(t)
+	}},
+	"kv": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.KV = true
+		return isStruct(t)
+	}},
+	"lower": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Lower = true
+		return elemKind(t) == reflect.String
+	}},
+	"max=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Max = arg
+		return isValidBound(elemKind(t), arg)
+	}},
+	"maxlen=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.MaxLen = arg
+		n, err := strconv.Atoi(arg)
+		return err == nil && n >= 0 && isStringOrBytes(t)
+	}},
+	"min=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Min = arg
+		return isValidBound(elemKind(t), arg)
+	}},
+	"minlen=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.MinLen = arg
+		n, err := strconv.Atoi(arg)
+		return err == nil && n >= 0 && isStringOrBytes(t)
+	}},
+	"minutes": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Minutes = true
+		return isInt(elemKind(t))
+	}},
+	"minutes=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Minutes = true
+		result.MinutesRound = true
+		return arg == "round" && isInt(elemKind(t))
+	}},
+	"msg=": {rest: true, parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Message = arg
+		return arg != ""
+	}},
+	"numbered": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Numbered = true
+		result.NumberFrom = 1
+		return t.Kind() == reflect.Slice && !isBytes(t)
+	}},
+	"numbered=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		from, err := strconv.Atoi(arg)
+		result.Numbered = true
+		result.NumberFrom = from
+		return err == nil && from >= 0 && t.Kind() == reflect.Slice && !isBytes(t)
+	}},
+	"oneof=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.OneOf = strings.Split(arg, "|")
+		return arg != "" && elemKind(t) == reflect.String
+	}},
+	"optional": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Optional = true
+		return true
+	}},
+	"pattern=": {rest: true, parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Pattern = arg
+		return arg != "" && elemKind(t) == reflect.String
+	}},
+	"poscsv": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.PosCSV = true
+		return isStruct(t)
+	}},
+	"prefix": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Prefix = true
+		return isStruct(t)
+	}},
+	"prefixmap": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.PrefixMap = true
+		return t.Kind() == reflect.Map && !reflect.PtrTo(t).Implements(jsonUnmarshalerType)
+	}},
+	"presence": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		// A flag that is absent is simply false, so it is never required
+		result.Presence = true
+		result.Optional = true
+		return elemKind(t) == reflect.Bool
+	}},
+	"salt=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Salt = arg
+		return arg != ""
+	}},
+	"secret": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Secret = true
+		return true
+	}},
+	"stdin": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Stdin = true
+		return isStringOrBytes(t)
+	}},
+	"thousands=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		// A comma separates the options, so it is written as comma
+		sep := arg
+		if sep == "comma" {
+			sep = ","
+		}
+		result.Thousands = sep
+		return utf8.RuneCountInString(sep) == 1 && (isIntOrUint(t) || isFloat(elemKind(t)))
+	}},
+	"unit": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Unit = true
+		return hasUnits(t)
+	}},
+	"unit=": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		_, ok := durationUnits[arg]
+		result.Unit = true
+		result.DurationUnit = arg
+		return ok && isDuration(t)
+	}},
+	"upper": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Upper = true
+		return elemKind(t) == reflect.String
+	}},
+	"wrap": {parse: func(result *tagData, t reflect.Type, arg string) bool {
+		result.Wrap = true
+		return isIntOrUint(t)
+	}},
+}
+
+// ValidTagOptions returns the sorted names of the tag options that are recognized,
+// including those registered with RegisterUnmarshaler, e.g. for tools that validate
+// tags statically. Options that take an argument end in "=", e.g. "default=".
+func ValidTagOptions() []string {
+	options := make([]string, 0, len(tagOptions))
+	for option := range tagOptions {
+		options = append(options, option)
+	}
+
+	unmarshalersMu.RLock()
+	for option := range unmarshalers {
+		options = append(options, option)
+	}
+	unmarshalersMu.RUnlock()
+
+	sort.Strings(options)
+	return options
+}
+
+// lookupTag returns the value of the first of the named tags that is present on the
+// field
+func lookupTag(f reflect.StructField, names []string) (string, bool) {
+	for _, name := range names {
+		if value, ok := f.Tag.Lookup(name); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func parseTag(f reflect.StructField, names []string, rules tagRules) (tagData, error) {
 	result := tagData{}
 
 	// Get the tags
 	var tags string
-	tags, result.Tagged = f.Tag.Lookup(tag)
+	tags, result.Tagged = lookupTag(f, names)
 	if !result.Tagged {
 		return result, nil
 	}
@@ -28,22 +352,291 @@ func parseTag(f reflect.StructField, tag string) (tagData, error) {
 
 	// Parse: Name
 	result.Name = tagTokens[0]
-	if len(result.Name) == 0 {
+	if len(result.Name) == 0 && !rules.AutoName {
 		return result, NewErrMissingNameTag(tags)
 	}
 
+	// ignored counts the unknown options that are ignored
+	ignored := 0
+
 	for i := 1; i < len(tagTokens); i++ {
-		switch tagTokens[i] {
-		case "base64":
-			result.Base64 = true
-		case "json":
-			result.JSON = true
-		case "optional":
-			result.Optional = true
-		default:
-			return tagData{}, NewErrInvalidTagOption(tags, tagTokens[i])
+		token := tagTokens[i]
+		name, arg, hasArg := strings.Cut(token, "=")
+		if hasArg {
+			name += "="
+		}
+
+		option, ok := tagOptions[name]
+		if !ok {
+			// Options registered with RegisterUnmarshaler, e.g. yaml
+			if _, ok := unmarshaler(token); ok {
+				if result.Unmarshaler != "" {
+					return tagData{}, NewErrInvalidTagOption(tags, token)
+				}
+				result.Unmarshaler = token
+				continue
+			}
+
+			// Unknown options are either skipped or an error
+			if rules.IgnoreUnknownOptions {
+				ignored++
+				continue
+			}
+			return tagData{}, NewErrInvalidTagOption(tags, token)
+		}
+
+		if option.rest {
+			// An option that follows it would be taken as part of the argument, e.g. a
+			// default of "a,msg=b" or a pattern of "^a+$,optional", so any other known
+			// option is an error
+			for _, next := range tagTokens[i+1:] {
+				name, _, hasArg := strings.Cut(next, "=")
+				if hasArg {
+					name += "="
+				}
+				if _, known := tagOptions[name]; known {
+					return tagData{}, NewErrInvalidTagOption(tags, token)
+				}
+			}
+
+			arg = strings.Join(append([]string{arg}, tagTokens[i+1:]...), ",")
+			i = len(tagTokens)
+		}
+
+		if !option.parse(&result, f.Type, arg) {
+			return tagData{}, NewErrInvalidTagOption(tags, token)
 		}
 	}
 
+	// Gzipped data is binary, so it must be base64-encoded unless read from stdin
+	if result.Gzip && !result.Base64 && !result.Stdin {
+		return tagData{}, NewErrInvalidTagOption(tags, "gzip")
+	}
+
+	// A list of durations is CSV unless it is encoded as a whole, e.g. 1s,2s,4s
+	if isDurationList(f.Type) && !result.JSON && result.Unmarshaler == "" && !result.Numbered {
+		result.CSV = true
+	}
+
+	// A list is either CSV or encoded as a whole
+	if result.CSV && (result.JSON || result.Unmarshaler != "") {
+		return tagData{}, NewErrInvalidTagOption(tags, "csv")
+	}
+
+	// The elements of a numbered slice are separate variables, so the slice cannot be
+	// decoded as a whole or have a default or alternate name
+	if result.Numbered && (result.CSV || result.JSON || result.Unmarshaler != "" || result.HasDefault || result.Alt != nil) {
+		return tagData{}, NewErrInvalidTagOption(tags, "numbered")
+	}
+
+	// Likewise, the elements of a map tagged with prefixmap are separate variables
+	if result.PrefixMap && (result.JSON || result.Unmarshaler != "" || result.HasDefault || result.Alt != nil) {
+		return tagData{}, NewErrInvalidTagOption(tags, "prefixmap")
+	}
+
+	// A flag that is present is simply true, so it is not written as an integer
+	if result.IntBool && result.Presence {
+		return tagData{}, NewErrConflictingOptions(tags, "intbool", "presence")
+	}
+
+	// A string cannot be both lowercased and uppercased
+	if result.Lower && result.Upper {
+		return tagData{}, NewErrConflictingOptions(tags, "lower", "upper")
+	}
+
+	// The value is read from either stdin or a file, not both
+	if result.FromFile && result.Stdin {
+		return tagData{}, NewErrInvalidTagOption(tags, "fromfile")
+	}
+
+	// Only one encoding can be used
+	if result.JSON && result.Unmarshaler != "" {
+		return tagData{}, NewErrInvalidTagOption(tags, result.Unmarshaler)
+	}
+	if result.KV && (result.JSON || result.Unmarshaler != "" || result.PosCSV) {
+		return tagData{}, NewErrInvalidTagOption(tags, "kv")
+	}
+
+	// Only one of base64 and hex can be used, and the decoded bytes are the value
+	if result.Hex && (result.Base64 || result.JSON || result.Unmarshaler != "") {
+		return tagData{}, NewErrInvalidTagOption(tags, "hex")
+	}
+
+	// A value is either a character or a number with units
+	if result.Char && result.Unit {
+		return tagData{}, NewErrInvalidTagOption(tags, "char")
+	}
+
+	// A character has no thousands
+	if result.Char && result.Thousands != "" {
+		return tagData{}, NewErrConflictingOptions(tags, "char", "thousands")
+	}
+
+	// Nor does it wrap around, which only applies to integers parsed as numbers
+	if result.Wrap && (result.Char || result.Unit) {
+		return tagData{}, NewErrInvalidTagOption(tags, "wrap")
+	}
+
+	// Nor does a number of minutes, which is written as a duration
+	if result.Minutes && (result.Char || result.Unit || result.Wrap || result.Base != "" || result.Thousands != "") {
+		return tagData{}, NewErrInvalidTagOption(tags, "minutes")
+	}
+
+	// base only applies to integers parsed as numbers
+	if result.Base != "" && (result.Char || result.Unit) {
+		return tagData{}, NewErrInvalidTagOption(tags, "base="+result.Base)
+	}
+
+	// fillmissing only applies to positional parsing
+	if result.FillMissing && !result.PosCSV {
+		return tagData{}, NewErrInvalidTagOption(tags, "fillmissing")
+	}
+
+	// fuzzy only applies to oneof
+	if result.Fuzzy && result.OneOf == nil {
+		return tagData{}, NewErrInvalidTagOption(tags, "fuzzy")
+	}
+
+	// kdf and salt must be used together
+	if result.KDF != "" && result.Salt == "" {
+		return tagData{}, NewErrInvalidTagOption(tags, "kdf")
+	}
+	if result.Salt != "" && result.KDF == "" {
+		return tagData{}, NewErrInvalidTagOption(tags, "salt")
+	}
+
+	// A prefix is not a variable, so it cannot be combined with any other option
+	if result.Prefix && len(tagTokens)-ignored > 2 {
+		return tagData{}, NewErrInvalidTagOption(tags, "prefix")
+	}
+
+	// Nor is a slice tagged with indexed
+	if result.Indexed && len(tagTokens)-ignored > 2 {
+		return tagData{}, NewErrInvalidTagOption(tags, "indexed")
+	}
+
 	return result, nil
 }
+
+// elem returns the tag for an element of the value, e.g. a key or a value of a map,
+// which is named on its own but decoded with the same settings of the Parser
+func (t tagData) elem(name string) tagData {
+	return tagData{
+		Name:        name,
+		CoerceFloat: t.CoerceFloat,
+		Truthy:      t.Truthy,
+	}
+}
+
+// base returns the base for parsing integers, which is 10 unless given in the tag.
+// A base of 0 means the base is implied by the prefix, e.g. 0x for hexadecimal.
+func (t tagData) base() int {
+	if t.Base == "" {
+		return 10
+	}
+
+	base, _ := strconv.Atoi(t.Base)
+	return base
+}
+
+// upperSnake converts a Go field name to UPPER_SNAKE_CASE, treating a run of capitals
+// as a single word, e.g. MaxConns becomes MAX_CONNS and HTTPPort becomes HTTP_PORT
+func upperSnake(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextIsLower {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+// isStruct returns true if the type is a struct or a pointer to a struct, other than a
+// *regexp.Regexp, which is compiled from its value rather than parsed, or a
+// sql.Scanner, e.g. sql.NullString, which scans its value
+func isStruct(t reflect.Type) bool {
+	if t == regexpType || isScanner(derefType(t)) {
+		return false
+	}
+
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+// isDurationList returns true if the type, dereferencing any pointers, is a slice of
+// time.Duration or of pointers to it
+func isDurationList(t reflect.Type) bool {
+	t = derefType(t)
+	return t.Kind() == reflect.Slice && derefType(t.Elem()) == durationType
+}
+
+// elemKind returns the kind of the type, dereferencing any pointers
+func elemKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind()
+}
+
+// derefType returns the type, dereferencing any pointers
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isBytes returns true if the type, dereferencing any pointers, is a []byte
+func isBytes(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isByteArray returns true if the type, dereferencing any pointers, is a [N]byte
+func isByteArray(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+// isStringOrBytes returns true if the type, dereferencing any pointers, is a string
+// or a []byte
+func isStringOrBytes(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isValidBound returns true if the bound can be parsed as a number of the given kind
+func isValidBound(k reflect.Kind, bound string) bool {
+	var err error
+
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(bound, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(bound, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(bound, 64)
+	default:
+		return false
+	}
+
+	return err == nil
+}
@@ -0,0 +1,35 @@
+package libconfig_test
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestValidTagOptions(t *testing.T) {
+	libconfig.RegisterUnmarshaler("validtagoptions", json.Unmarshal)
+	options := libconfig.ValidTagOptions()
+
+	require := require.New(t)
+	require.True(sort.StringsAreSorted(options), "the options should be sorted")
+	require.Contains(options, "base64", "the options should include flags")
+	require.Contains(options, "default=", "the options should include options that take an argument")
+	require.Contains(options, "validtagoptions", "the options should include registered unmarshalers")
+}
+
+func TestValidTagOptionsOnly(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A,optional=yes"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("VAR_A,optional=yes", "optional=yes")
+
+	require := require.New(t)
+	require.Equal(expected, err, "a flag should not accept an argument")
+}
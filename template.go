@@ -0,0 +1,122 @@
+package libconfig
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Template returns a sample env file for the config, which may be a struct or a pointer
+// to a struct, listing every variable in declaration order with a comment describing
+// whether it is required and its type, e.g.
+//
+//	# required, int
+//	MAX_CONNS=
+//
+// The value is the default given in the tag, if any. The variables of nested structs
+// are grouped under a heading with the path of the struct, and a slice tagged with
+// indexed is shown with a single element.
+func (p *Parser) Template(config interface{}) (string, error) {
+	t, err := configType(config)
+	if err != nil {
+		return "", err
+	}
+
+	var b templateBuilder
+	err = p.template(&b, t, p.Prefix, "")
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// template writes the variables of the struct type, following the same rules for
+// nested structs as parse
+func (p *Parser) template(b *templateBuilder, t reflect.Type, prefix, path string) error {
+	fields, err := p.fields(t, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		tag := f.Tag
+		fieldPath := path + f.Field.Name
+
+		if tag.Indexed {
+			writeTemplateGroup(b, fieldPath+"[0]")
+			err = p.template(b, f.Field.Type.Elem(), indexedPrefix(tag.Name, 0), fieldPath+"[0].")
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tag.Tagged && !tag.Prefix {
+			writeTemplateVar(b, f.Field.Type, tag)
+			continue
+		}
+
+		if f.IsStruct {
+			ft := f.Field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			nestedPrefix := prefix
+			if tag.Prefix {
+				nestedPrefix = tag.Name
+			}
+
+			writeTemplateGroup(b, fieldPath)
+			err = p.template(b, ft, nestedPrefix, fieldPath+".")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// templateBuilder accumulates the template, separating each entry from the previous
+// one with a blank line unless it directly follows a group heading
+type templateBuilder struct {
+	strings.Builder
+	heading bool
+}
+
+// separate writes the blank line before an entry when one is needed
+func (b *templateBuilder) separate() {
+	if b.Len() > 0 && !b.heading {
+		b.WriteString("\n")
+	}
+}
+
+// writeTemplateGroup writes the heading for the variables of a nested struct
+func writeTemplateGroup(b *templateBuilder, path string) {
+	b.separate()
+	b.WriteString("## " + path + "\n")
+	b.heading = true
+}
+
+// writeTemplateVar writes the comment and the assignment for a single variable
+func writeTemplateVar(b *templateBuilder, t reflect.Type, tag tagData) {
+	b.separate()
+
+	required := "required"
+	if tag.Optional || tag.HasDefault {
+		required = "optional"
+	}
+
+	b.WriteString("# " + required + ", " + t.String() + "\n")
+	name := tag.Name
+	if tag.Numbered {
+		name = numberedName(tag.Name, tag.NumberFrom)
+	}
+	if tag.PrefixMap {
+		name = tag.Name + "KEY"
+	}
+
+	b.WriteString(name + "=" + tag.Default + "\n")
+	b.heading = false
+}
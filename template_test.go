@@ -0,0 +1,82 @@
+package libconfig_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestTemplate(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=5432"`
+	}
+	type Server struct {
+		Addr string `env:"ADDR"`
+	}
+	type Config struct {
+		LogLevel string        `env:"LOG_LEVEL"`
+		Timeout  time.Duration `env:"TIMEOUT,unit,optional"`
+		Untagged string
+		Database `env:"DB_,prefix"`
+		Servers  []Server `env:"SERVER,indexed"`
+	}
+
+	p := mapToParser(nil)
+	p.Prefix = "APP_"
+
+	template, err := p.Template(&Config{})
+	expected := `# required, string
+APP_LOG_LEVEL=
+
+# optional, time.Duration
+APP_TIMEOUT=
+
+## Database
+# required, string
+APP_DB_HOST=
+
+# optional, int
+APP_DB_PORT=5432
+
+## Servers[0]
+# required, string
+APP_SERVER_0_ADDR=
+`
+
+	require := require.New(t)
+	require.NoError(err, "Template should not fail")
+	require.Equal(expected, template, "the template should list every variable")
+}
+
+func TestTemplateStructValue(t *testing.T) {
+	type Config struct {
+		LogLevel string `env:"LOG_LEVEL"`
+	}
+
+	p := mapToParser(nil)
+	fromValue, err := p.Template(Config{})
+
+	require := require.New(t)
+	require.NoError(err, "Template should accept a struct value")
+
+	fromPointer, err := p.Template(&Config{})
+	require.NoError(err, "Template should accept a pointer")
+	require.Equal(fromPointer, fromValue, "the template should be the same for a value and a pointer")
+}
+
+func TestTemplateBadTag(t *testing.T) {
+	type Config struct {
+		VarA string `env:""`
+	}
+
+	p := mapToParser(nil)
+	_, err := p.Template(&Config{})
+	expected := libconfig.NewErrMissingNameTag("")
+
+	require := require.New(t)
+	require.Equal(expected, err, "Template should fail because of the tag")
+}
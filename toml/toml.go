@@ -0,0 +1,24 @@
+// Package toml adds a "toml" tag option to libconfig, which decodes the value of a
+// variable as TOML in the same way that the json option decodes JSON. It is a separate
+// package so that libconfig itself does not depend on a TOML library. Import it for
+// its side effect:
+//
+//	import _ "github.com/jrudder/libconfig/toml"
+//
+//	type Config struct {
+//	    Database Database `env:"DATABASE,toml"`
+//
+//	    // Base64 and TOML can be used together
+//	    Limits map[string]int `env:"LIMITS,base64,toml"`
+//	}
+package toml
+
+import (
+	burntsushi "github.com/BurntSushi/toml"
+
+	"github.com/jrudder/libconfig"
+)
+
+func init() {
+	libconfig.RegisterUnmarshaler("toml", burntsushi.Unmarshal)
+}
@@ -0,0 +1,106 @@
+package toml_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+	_ "github.com/jrudder/libconfig/toml"
+)
+
+type Replica struct {
+	Host string `toml:"host"`
+	Port int    `toml:"port"`
+}
+
+type Database struct {
+	Name    string  `toml:"name"`
+	Primary Replica `toml:"primary"`
+}
+
+const databaseTOML = `name = "app"
+
+[primary]
+host = "db.example.com"
+port = 5432
+`
+
+func TestTOML(t *testing.T) {
+	type Config struct {
+		Database Database  `env:"DATABASE,toml"`
+		Pointer  *Database `env:"POINTER,toml"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DATABASE": databaseTOML,
+		"POINTER":  `name = "other"`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+	expected := Database{
+		Name:    "app",
+		Primary: Replica{Host: "db.example.com", Port: 5432},
+	}
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(expected, config.Database, "Database should parse correctly")
+	require.Equal(&Database{Name: "other"}, config.Pointer, "Pointer should be allocated and parsed")
+}
+
+func TestTOMLBase64(t *testing.T) {
+	type Config struct {
+		Database Database `env:"DATABASE,base64,toml"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DATABASE": base64.StdEncoding.EncodeToString([]byte(databaseTOML)),
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal("db.example.com", config.Database.Primary.Host, "Database should parse correctly")
+}
+
+func TestTOMLInvalid(t *testing.T) {
+	type Config struct {
+		Database Database `env:"DATABASE,toml"`
+	}
+
+	p := mapToParser(map[string]string{
+		"DATABASE": "name = ",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as TOML")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("toml", specificErr.Type, "the error should be for toml")
+	require.Error(specificErr.Because, "Because should be set")
+}
+
+func TestTOMLAndJSON(t *testing.T) {
+	type Config struct {
+		Database Database `env:"DATABASE,json,toml"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("DATABASE,json,toml", "toml")
+
+	require := require.New(t)
+	require.Equal(expected, err, "json and toml cannot be used together")
+}
+
+func mapToParser(envs map[string]string) libconfig.Parser {
+	return *libconfig.NewMapParser(envs, "env")
+}
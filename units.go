@@ -0,0 +1,232 @@
+package libconfig
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ErrPartialMinute is the cause of the ErrCannotParseEnv returned if a value tagged
+// with minutes is not a whole number of minutes, e.g. 90s, unless it has minutes=round
+var ErrPartialMinute = errors.New("duration is not a whole number of minutes")
+
+// byteUnits maps the (lowercase) suffixes of byte sizes to their multipliers. The SI
+// suffixes are decimal, while the IEC suffixes are binary.
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// durationUnits maps the units that can be given as unit=<unit> for a time.Duration to
+// the duration of one unit
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// isDuration returns true if the type, dereferencing any pointers, is a time.Duration
+func isDuration(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t == durationType
+}
+
+// hasUnits returns true if the type, dereferencing any pointers, has known unit
+// semantics: time.Duration is a duration and any other integer is a size in bytes
+func hasUnits(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t == durationType || isInt(t.Kind()) || isUint(t.Kind())
+}
+
+// setValueWithUnits parses a human-readable value, choosing the conversion by the
+// type of v: a time.Duration accepts "1h30m" or "01:30:00", or a bare number of the
+// unit if one is given, and an integer accepts a size in bytes such as "512", "10MB",
+// or "1.5GiB"
+func setValueWithUnits(v reflect.Value, key, value string, unit time.Duration) error {
+	k := v.Kind()
+
+	if v.Type() == durationType {
+		d, err := parseDuration(value, unit)
+		if err != nil {
+			return NewErrCannotParseEnv(err, k, key, value)
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	// A plain number of bytes is parsed exactly, since a float64 cannot hold every
+	// 64-bit integer
+	if isUint(k) {
+		if u, err := strconv.ParseUint(value, 10, 64); err == nil {
+			if v.OverflowUint(u) {
+				return NewErrOverflow(k, key, value)
+			}
+			v.SetUint(u)
+			return nil
+		}
+	} else if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if v.OverflowInt(i) {
+			return NewErrOverflow(k, key, value)
+		}
+		v.SetInt(i)
+		return nil
+	}
+
+	size, err := parseSize(value)
+	if err != nil {
+		return NewErrCannotParseEnv(err, k, key, value)
+	}
+
+	// The bounds are exact powers of two, which a float64 can hold, unlike
+	// math.MaxInt64 and math.MaxUint64
+	if isUint(k) {
+		if size < 0 {
+			return NewErrCannotParseEnv(fmt.Errorf("size cannot be negative"), k, key, value)
+		}
+		if size >= 1<<64 || v.OverflowUint(uint64(size)) {
+			return NewErrOverflow(k, key, value)
+		}
+
+		v.SetUint(uint64(size))
+		return nil
+	}
+
+	if size >= 1<<63 || size < -(1<<63) || v.OverflowInt(int64(size)) {
+		return NewErrOverflow(k, key, value)
+	}
+
+	v.SetInt(int64(size))
+	return nil
+}
+
+// setValueToMinutes parses the value as a duration, like a time.Duration, and sets the
+// integer to the number of minutes. A remainder of less than a minute is an error
+// unless round is set, in which case the minutes are rounded down.
+func setValueToMinutes(v reflect.Value, key, value string, round bool) error {
+	k := v.Kind()
+
+	d, err := parseDuration(value, 0)
+	if err != nil {
+		return NewErrCannotParseEnv(err, k, key, value)
+	}
+
+	remainder := d % time.Minute
+	if remainder != 0 && !round {
+		return NewErrCannotParseEnv(ErrPartialMinute, k, key, value)
+	}
+
+	minutes := int64(d / time.Minute)
+	if remainder < 0 {
+		minutes--
+	}
+
+	if v.OverflowInt(minutes) {
+		return NewErrOverflow(k, key, value)
+	}
+
+	v.SetInt(minutes)
+	return nil
+}
+
+// parseDuration parses a Go duration such as "1h30m" or a clock time such as "01:30:00".
+// If the unit is not zero, a bare number such as "30" or "1.5" is a number of the unit.
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	if unit != 0 {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			d := n * float64(unit)
+			if math.IsNaN(d) || d >= 1<<63 || d < -(1<<63) {
+				return 0, fmt.Errorf("invalid duration [%s]", value)
+			}
+
+			return time.Duration(d), nil
+		}
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return time.ParseDuration(value)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil || i > 0 && n > 59 {
+			return 0, fmt.Errorf("invalid clock time [%s]", value)
+		}
+		d += time.Duration(n) * unit
+	}
+
+	return d, nil
+}
+
+// parseSize parses a size in bytes with an optional unit suffix, returning an error
+// if the size is not a whole number of bytes
+func parseSize(value string) (float64, error) {
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.' || r == '-' || r == '+')
+	})
+	if i < 0 {
+		i = len(value)
+	}
+
+	n, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(value[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit [%s]", value[i:])
+	}
+
+	size := n * unit
+	if size != math.Trunc(size) {
+		return 0, fmt.Errorf("size is not a whole number of bytes")
+	}
+
+	return size, nil
+}
+
+// isInt returns true if the kind is a signed integer
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+// isFloat returns true if the kind is a floating-point number
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// isUint returns true if the kind is an unsigned integer (excluding uintptr)
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
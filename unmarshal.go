@@ -0,0 +1,98 @@
+package libconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// UnmarshalFunc decodes data into the value pointed to by v, like json.Unmarshal
+type UnmarshalFunc func(data []byte, v interface{}) error
+
+var (
+	unmarshalersMu sync.RWMutex
+	unmarshalers   = map[string]UnmarshalFunc{}
+)
+
+// RegisterUnmarshaler makes a tag option available to every Parser that decodes the
+// value with the given function, in the same way that the json option does. It is
+// intended to be called from the init function of a package such as
+// github.com/jrudder/libconfig/yaml, so that the core does not depend on every
+// encoding. The names of built-in options cannot be registered.
+func RegisterUnmarshaler(option string, fn UnmarshalFunc) {
+	unmarshalersMu.Lock()
+	defer unmarshalersMu.Unlock()
+
+	unmarshalers[option] = fn
+}
+
+// unmarshaler returns the function registered for the option, if any
+func unmarshaler(option string) (UnmarshalFunc, bool) {
+	unmarshalersMu.RLock()
+	defer unmarshalersMu.RUnlock()
+
+	fn, ok := unmarshalers[option]
+	return fn, ok
+}
+
+// unmarshalJSONStrict is like json.Unmarshal, but returns an error if an object has a
+// key that does not match any field of the destination struct
+func unmarshalJSONStrict(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+
+	err := d.Decode(v)
+	if err != nil {
+		return err
+	}
+
+	// Like json.Unmarshal, reject anything after the value
+	if _, err := d.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+
+	return nil
+}
+
+// rawMessageType is the type of json.RawMessage, which holds JSON without decoding it
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// errInvalidJSON is the cause of an ErrDecodeFailure for a json.RawMessage that does
+// not hold valid JSON
+var errInvalidJSON = errors.New("invalid JSON")
+
+// setRawMessage stores a copy of the bytes in v, which is a json.RawMessage, if they
+// are valid JSON
+func setRawMessage(v reflect.Value, tag tagData, value string, data []byte) error {
+	if !json.Valid(data) {
+		return NewErrDecodeFailure(errInvalidJSON, tag.Name, value, "json")
+	}
+
+	v.SetBytes(append(json.RawMessage(nil), data...))
+
+	return nil
+}
+
+// unmarshal decodes the bytes into v with the function, allocating memory if v is a
+// nil pointer. Errors are returned as an ErrDecodeFailure of the given type.
+func unmarshal(v reflect.Value, tag tagData, value string, bytes []byte, fn UnmarshalFunc, typ string) error {
+	if v.Kind() == reflect.Ptr {
+		// If v is a nil pointer, we need to allocate memory
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+	} else {
+		// We need a pointer to the struct for unmarshalling
+		v = v.Addr()
+	}
+
+	err := fn(bytes, v.Interface())
+	if err != nil {
+		return NewErrDecodeFailure(err, tag.Name, value, typ)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package libconfig
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrEnumUnavailable is returned by UnusedVars if the Parser has no EnumFn
+var ErrEnumUnavailable = errors.New("cannot enumerate variables because the parser has no EnumFn")
+
+// UnusedVars returns the sorted names of the variables listed by the EnumFn that have
+// the Parser's Prefix but are not consumed by any field of the config, which are
+// likely typos. With an AliasFn, the names are compared after they are mapped, and a
+// name also has the Prefix if it begins with the mapped Prefix, e.g. PLATFORM_APP_
+// for APP_. The config may be a struct or a pointer to a struct. Without a Prefix,
+// every variable that is listed has it, so with an EnumFn that lists the environment,
+// unrelated variables such as PATH and HOME are reported too.
+func (p *Parser) UnusedVars(config interface{}) ([]string, error) {
+	t, err := configType(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.EnumFn == nil {
+		return nil, ErrEnumUnavailable
+	}
+
+	used := map[string]bool{}
+	err = p.names(t, p.Prefix, used)
+	if err != nil {
+		return nil, err
+	}
+
+	// The EnumFn lists the names that are passed to the lookup function
+	aliased := map[string]bool{}
+	for name := range used {
+		aliased[p.alias(name)] = true
+	}
+
+	// The EnumFn lists names after they are mapped, which may move them out of the
+	// Prefix, e.g. to PLATFORM_APP_A for APP_A, so the mapped Prefix applies as well
+	prefix := p.alias(p.Prefix)
+
+	unused := []string{}
+	for _, name := range p.EnumFn() {
+		hasPrefix := strings.HasPrefix(name, p.Prefix) || strings.HasPrefix(name, prefix)
+		if hasPrefix && !aliased[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+
+	return unused, nil
+}
+
+// names adds the name of every variable consumed by the struct type to the set,
+// following the same rules for nested structs as parse
+func (p *Parser) names(t reflect.Type, prefix string, set map[string]bool) error {
+	fields, err := p.fields(t, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		tag := f.Tag
+		if tag.Indexed {
+			err = p.indexedNames(f.Field.Type.Elem(), tag.Name, set)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tag.Numbered {
+			err = p.numberedNames(tag, set)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tag.PrefixMap {
+			names, err := p.prefixMapNames(tag)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				set[name] = true
+			}
+			continue
+		}
+
+		if tag.Tagged && !tag.Prefix {
+			set[tag.Name] = true
+			for _, alt := range tag.Alt {
+				set[alt] = true
+			}
+			if tag.Salt != "" {
+				set[tag.Salt] = true
+			}
+			continue
+		}
+
+		nestedPrefix := prefix
+		if tag.Prefix {
+			nestedPrefix = tag.Name
+		}
+
+		if f.IsStruct {
+			ft := f.Field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			err = p.names(ft, nestedPrefix, set)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package libconfig_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+func TestUnusedVars(t *testing.T) {
+	type Config struct {
+		VarA   string `env:"VAR_A"`
+		Nested *struct {
+			VarB int `env:"VAR_B,optional"`
+		}
+		VarC struct {
+			Ignored string `json:"ignored"`
+		} `env:"VAR_C,json"`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = func() []string {
+		return []string{"VAR_C", "VAR_D", "VAR_A", "VAR_AA", "VAR_B"}
+	}
+
+	config := Config{}
+	unused, err := p.UnusedVars(&config)
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"VAR_AA", "VAR_D"}, unused, "unused should contain the unconsumed vars")
+	require.Nil(config.Nested, "UnusedVars should not modify the config")
+}
+
+func TestUnusedVarsStructValue(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = func() []string {
+		return []string{"VAR_A", "VAR_B"}
+	}
+
+	unused, err := p.UnusedVars(Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should accept a struct value")
+	require.Equal([]string{"VAR_B"}, unused, "unused should be the same as for a pointer")
+}
+
+func TestUnusedVarsPrefix(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(nil)
+	p.Prefix = "APP_"
+	p.EnumFn = func() []string {
+		return []string{"APP_VAR_A", "APP_VAR_TYPO", "PATH", "VAR_A"}
+	}
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"APP_VAR_TYPO"}, unused, "unused should only contain vars with the prefix")
+}
+
+func TestUnusedVarsWithoutPrefix(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = func() []string {
+		return []string{"HOME", "PATH", "VAR_A"}
+	}
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"HOME", "PATH"}, unused, "without a prefix, every unconsumed var should be reported")
+}
+
+func TestUnusedVarsAliasFn(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(nil)
+	p.AliasFn = strings.ToLower
+	p.EnumFn = func() []string {
+		return []string{"var_a", "var_b"}
+	}
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"var_b"}, unused, "used vars should be compared by their aliases")
+}
+
+func TestUnusedVarsAliasFnOutsidePrefix(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(nil)
+	p.Prefix = "APP_"
+	p.AliasFn = func(name string) string { return "PLATFORM_" + name }
+	p.EnumFn = func() []string {
+		return []string{"PLATFORM_APP_VAR_A", "PLATFORM_APP_VAR_TYPO", "PLATFORM_OTHER", "PATH"}
+	}
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"PLATFORM_APP_VAR_TYPO"}, unused, "vars with the mapped prefix should be reported")
+}
+
+func TestUnusedVarsEmbeddedPrefix(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		Database `env:"DB_,prefix"`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = func() []string {
+		return []string{"DB_HOST", "HOST"}
+	}
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"HOST"}, unused, "the embedded struct should use the prefix")
+}
+
+func TestUnusedVarsSalt(t *testing.T) {
+	type Config struct {
+		Key []byte `env:"PASSPHRASE,kdf=sha256,salt=KEY_SALT"`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = func() []string {
+		return []string{"PASSPHRASE", "KEY_SALT", "KEY_SALTY"}
+	}
+
+	unused, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Equal([]string{"KEY_SALTY"}, unused, "the salt should be consumed by the field")
+}
+
+func TestUnusedVarsWithoutEnumFn(t *testing.T) {
+	type Config struct {
+		VarA string `env:"VAR_A"`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = nil
+	_, err := p.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.Equal(libconfig.ErrEnumUnavailable, err, "UnusedVars should fail without an EnumFn")
+}
+
+func TestUnusedVarsBadTag(t *testing.T) {
+	type Config struct {
+		VarA string `env:""`
+	}
+
+	p := mapToParser(nil)
+	p.EnumFn = func() []string { return nil }
+	_, err := p.UnusedVars(&Config{})
+	expected := libconfig.NewErrMissingNameTag("")
+
+	require := require.New(t)
+	require.Equal(expected, err, "UnusedVars should fail because of the tag")
+}
+
+func TestUnusedVarsInvalidConfigType(t *testing.T) {
+	p := mapToParser(nil)
+
+	var config int
+	_, err := p.UnusedVars(config)
+	expected := libconfig.NewErrInvalidConfigType(reflect.TypeOf(config))
+
+	require := require.New(t)
+	require.Equal(expected, err, "UnusedVars should fail with ErrInvalidConfigType")
+}
+
+func TestUnusedVarsSingleton(t *testing.T) {
+	os.Setenv("LIBCONFIG_UNUSED_SINGLETON", "value")
+	defer os.Unsetenv("LIBCONFIG_UNUSED_SINGLETON")
+
+	type Config struct {
+		Value string `env:"LIBCONFIG_SINGLETON"`
+	}
+
+	unused, err := libconfig.UnusedVars(&Config{})
+
+	require := require.New(t)
+	require.NoError(err, "UnusedVars should not fail")
+	require.Contains(unused, "LIBCONFIG_UNUSED_SINGLETON", "unused should contain the unconsumed var")
+	require.NotContains(unused, "LIBCONFIG_SINGLETON", "unused should not contain the consumed var")
+}
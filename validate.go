@@ -0,0 +1,171 @@
+package libconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// validate checks the value of v against the validation options of the tag
+func validate(v reflect.Value, tag tagData) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if tag.OneOf != nil {
+		allowed, ok := match(tag.OneOf, v.String(), tag.Fuzzy)
+		if !ok {
+			return NewErrNotInEnum(tag.Name, v.String(), tag.OneOf)
+		}
+
+		// Assign the canonical value, which only differs from a fuzzy match
+		v.SetString(allowed)
+	}
+
+	if tag.Min != "" && compare(v, tag.Min) < 0 || tag.Max != "" && compare(v, tag.Max) > 0 {
+		return NewErrOutOfRange(tag.Name, fmt.Sprint(v.Interface()), tag.Min, tag.Max)
+	}
+
+	if tag.Regexp != nil && !tag.Regexp.MatchString(v.String()) {
+		return NewErrPatternMismatch(tag.Name, v.String(), tag.Pattern)
+	}
+
+	if tag.MinLen != "" || tag.MaxLen != "" {
+		// Strings are measured in runes, but []byte is measured in bytes
+		n := v.Len()
+		if v.Kind() == reflect.String {
+			n = utf8.RuneCountInString(v.String())
+		}
+
+		min, _ := strconv.Atoi(tag.MinLen)
+		max, err := strconv.Atoi(tag.MaxLen)
+		if n < min || err == nil && n > max {
+			return NewErrLengthViolation(tag.Name, n, tag.MinLen, tag.MaxLen)
+		}
+	}
+
+	return nil
+}
+
+// compare returns -1, 0, or 1 if the numeric value of v is less than, equal to, or
+// greater than the bound, which must already be known to parse as the kind of v
+func compare(v reflect.Value, bound string) int {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b, _ := strconv.ParseInt(bound, 10, 64)
+		return sign(v.Int() > b, v.Int() < b)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b, _ := strconv.ParseUint(bound, 10, 64)
+		return sign(v.Uint() > b, v.Uint() < b)
+	default:
+		b, _ := strconv.ParseFloat(bound, 64)
+		return sign(v.Float() > b, v.Float() < b)
+	}
+}
+
+// sign converts the results of a comparison to -1, 0, or 1
+func sign(greater, less bool) int {
+	if greater {
+		return 1
+	}
+	if less {
+		return -1
+	}
+
+	return 0
+}
+
+// match returns the item in the list that matches the value. A fuzzy match ignores
+// case, hyphens, and underscores, so "Log-Fmt" matches "logfmt".
+func match(list []string, value string, fuzzy bool) (string, bool) {
+	if fuzzy {
+		value = normalize(value)
+	}
+
+	for _, item := range list {
+		if item == value || fuzzy && normalize(item) == value {
+			return item, true
+		}
+	}
+
+	return "", false
+}
+
+// normalize lowercases the value and removes hyphens and underscores
+func normalize(value string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(value))
+}
+
+// Validator is implemented by types that check their own invariants, e.g. that the
+// required fields of a struct decoded from JSON are set, since the fields of such a
+// struct cannot be tagged as variables
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// validateDecoded calls Validate on a value decoded as a whole, e.g. with json, if it
+// implements Validator, and then on each of its elements if it is a slice, an array,
+// or a map, in order of index or key. It returns an ErrValidation for the first that
+// fails, identifying an element by its index or key, e.g. SERVERS[1].
+func validateDecoded(v reflect.Value, key string) error {
+	v, ok := indirect(v)
+	if !ok {
+		return nil
+	}
+
+	if validator, ok := asValidator(v); ok {
+		err := validator.Validate()
+		if err != nil {
+			return NewErrValidation(key, err)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if isBytes(v.Type()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			err := validateDecoded(v.Index(i), key+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+
+		for _, k := range keys {
+			err := validateDecoded(v.MapIndex(k), fmt.Sprintf("%s[%v]", key, k))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// asValidator returns the value as a Validator if either it or a pointer to it
+// implements the interface
+func asValidator(v reflect.Value) (Validator, bool) {
+	if v.Type().Implements(validatorType) {
+		return v.Interface().(Validator), true
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(validatorType) {
+		return v.Addr().Interface().(Validator), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,90 @@
+package libconfig_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+)
+
+type validatedServer struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+var errMissingHost = errors.New("host is required")
+
+func (s validatedServer) Validate() error {
+	if s.Host == "" {
+		return errMissingHost
+	}
+
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	type Config struct {
+		Primary validatedServer   `env:"PRIMARY,json"`
+		Servers []validatedServer `env:"SERVERS,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"PRIMARY": `{"host":"a.example.com","port":80}`,
+		"SERVERS": `[{"host":"b.example.com","port":80},{"host":"c.example.com","port":443}]`,
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Len(config.Servers, 2, "Servers should be decoded")
+}
+
+func TestValidatorElementFails(t *testing.T) {
+	type Config struct {
+		Servers []validatedServer `env:"SERVERS,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVERS": `[{"host":"a.example.com","port":80},{"port":443}]`,
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrValidation("SERVERS[1]", errMissingHost)
+
+	require := require.New(t)
+	require.Equal(expected, err, "Get should fail because the second server has no host")
+	require.True(errors.Is(err, errMissingHost), "the error should wrap the error from Validate")
+}
+
+func TestValidatorMapValueFails(t *testing.T) {
+	type Config struct {
+		Servers map[string]*validatedServer `env:"SERVERS,json"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVERS": `{"a":{"host":"a.example.com"},"b":{"port":443}}`,
+	})
+
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrValidation("SERVERS[b]", errMissingHost)
+
+	require.Equal(t, expected, err, "Get should fail because server b has no host")
+}
+
+func TestValidatorWithoutJSON(t *testing.T) {
+	type Config struct {
+		Server validatedServer `env:"SERVER,poscsv"`
+	}
+
+	p := mapToParser(map[string]string{
+		"SERVER": ",80",
+	})
+
+	err := p.Get(&Config{})
+
+	require.NoError(t, err, "Validate should only be called after decoding json")
+}
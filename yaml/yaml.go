@@ -0,0 +1,24 @@
+// Package yaml adds a "yaml" tag option to libconfig, which decodes the value of a
+// variable as YAML in the same way that the json option decodes JSON. It is a separate
+// package so that libconfig itself does not depend on a YAML library. Import it for
+// its side effect:
+//
+//	import _ "github.com/jrudder/libconfig/yaml"
+//
+//	type Config struct {
+//	    Servers []Server `env:"SERVERS,yaml"`
+//
+//	    // Base64 and YAML can be used together
+//	    Routes map[string]string `env:"ROUTES,base64,yaml"`
+//	}
+package yaml
+
+import (
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/jrudder/libconfig"
+)
+
+func init() {
+	libconfig.RegisterUnmarshaler("yaml", yamlv3.Unmarshal)
+}
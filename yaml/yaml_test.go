@@ -0,0 +1,92 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jrudder/libconfig"
+	_ "github.com/jrudder/libconfig/yaml"
+)
+
+type Nested struct {
+	VarC int    `yaml:"varc"`
+	VarD string `yaml:"vard"`
+}
+
+func TestYAML(t *testing.T) {
+	type Config struct {
+		Nested  Nested   `env:"NESTED,yaml"`
+		Pointer *Nested  `env:"POINTER,yaml"`
+		Array   []string `env:"ARRAY,yaml"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED":  "varc: 10\nvard: val_d",
+		"POINTER": "{varc: 20, vard: val_e}",
+		"ARRAY":   "- a\n- b",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(Nested{VarC: 10, VarD: "val_d"}, config.Nested, "Nested should parse correctly")
+	require.Equal(&Nested{VarC: 20, VarD: "val_e"}, config.Pointer, "Pointer should parse correctly")
+	require.Equal([]string{"a", "b"}, config.Array, "Array should parse correctly")
+}
+
+func TestYAMLBase64(t *testing.T) {
+	type Config struct {
+		Nested Nested `env:"NESTED,base64,yaml"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": "dmFyYzogMTAKdmFyZDogdmFsX2Q=",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.NoError(err, "Get should not fail")
+	require.Equal(Nested{VarC: 10, VarD: "val_d"}, config.Nested, "Nested should parse correctly")
+}
+
+func TestYAMLInvalid(t *testing.T) {
+	type Config struct {
+		Nested Nested `env:"NESTED,yaml"`
+	}
+
+	p := mapToParser(map[string]string{
+		"NESTED": "varc: [not-an-int",
+	})
+
+	config := Config{}
+	err := p.Get(&config)
+
+	require := require.New(t)
+	require.Error(err, "Get should fail to parse the value as YAML")
+	specificErr, ok := err.(*libconfig.ErrDecodeFailure)
+	require.True(ok, "the error should be ErrDecodeFailure")
+	require.Equal("yaml", specificErr.Type, "the error should be for yaml")
+	require.Error(specificErr.Because, "Because should be set")
+}
+
+func TestYAMLAndJSON(t *testing.T) {
+	type Config struct {
+		Nested Nested `env:"NESTED,json,yaml"`
+	}
+
+	p := mapToParser(nil)
+	err := p.Get(&Config{})
+	expected := libconfig.NewErrInvalidTagOption("NESTED,json,yaml", "yaml")
+
+	require := require.New(t)
+	require.Equal(expected, err, "json and yaml cannot be used together")
+}
+
+func mapToParser(envs map[string]string) libconfig.Parser {
+	return *libconfig.NewMapParser(envs, "env")
+}